@@ -0,0 +1,130 @@
+package idlgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"reflect"
+)
+
+// --- AST Output ---
+
+// astField describes a struct field built by astStructDecl.
+type astField struct {
+	Name    string   // Go field name
+	Type    string   // Go type expression, e.g. "[]*solana.PublicKey"
+	Tag     string   // struct tag literal, backquotes included
+	Docs    []string // comment lines above the field
+	Comment string   // trailing comment, empty for none
+}
+
+// astStructDecl renders the declaration of struct name, generic over
+// typeParams if any, with its doc comment by building it with go/ast and
+// printing it with go/printer, so a malformed name, type or tag fails here
+// instead of producing source format.Source rejects.
+func astStructDecl(name, doc string, typeParams []string, fields []astField) (string, error) {
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("invalid struct name %q", name)
+	}
+	// Every comment and field gets its own line, so go/printer places the
+	// comments where they belong. A line spans two offsets: the field
+	// itself and its trailing comment.
+	lineCount := 4 + len(fields)
+	for _, f := range fields {
+		lineCount += len(f.Docs)
+	}
+	fset := token.NewFileSet()
+	file := fset.AddFile("", -1, 2*lineCount)
+	offsets := make([]int, lineCount)
+	for i := range offsets {
+		offsets[i] = 2 * i
+	}
+	file.SetLines(offsets)
+	line := 0
+	nextLine := func() token.Pos {
+		line++
+		return file.Pos(2 * line)
+	}
+	comments := func(lines []string) *ast.CommentGroup {
+		if len(lines) == 0 {
+			return nil
+		}
+		g := &ast.CommentGroup{}
+		for _, text := range lines {
+			g.List = append(g.List, &ast.Comment{Slash: nextLine(), Text: "// " + text})
+		}
+		return g
+	}
+
+	declDoc := comments([]string{doc})
+	declPos := nextLine()
+	list := &ast.FieldList{Opening: declPos}
+	for _, f := range fields {
+		if !token.IsIdentifier(f.Name) {
+			return "", fmt.Errorf("struct %s: invalid field name %q", name, f.Name)
+		}
+		typ, err := parser.ParseExpr(f.Type)
+		if err != nil {
+			return "", fmt.Errorf("struct %s: field %s has invalid type %q: %v", name, f.Name, f.Type, err)
+		}
+		field := &ast.Field{Doc: comments(f.Docs)}
+		pos := nextLine()
+		setPositions(typ, pos)
+		field.Names = []*ast.Ident{{NamePos: pos, Name: f.Name}}
+		field.Type = typ
+		field.Tag = &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: f.Tag}
+		if f.Comment != "" {
+			field.Comment = &ast.CommentGroup{List: []*ast.Comment{{Slash: pos + 1, Text: "// " + f.Comment}}}
+		}
+		list.List = append(list.List, field)
+	}
+	list.Closing = nextLine()
+
+	spec := &ast.TypeSpec{
+		Name: &ast.Ident{NamePos: declPos, Name: name},
+		Type: &ast.StructType{Struct: declPos, Fields: list},
+	}
+	if len(typeParams) > 0 {
+		param := &ast.Field{Type: &ast.Ident{NamePos: declPos, Name: "any"}}
+		for _, p := range typeParams {
+			if !token.IsIdentifier(p) {
+				return "", fmt.Errorf("struct %s: invalid type parameter %q", name, p)
+			}
+			param.Names = append(param.Names, &ast.Ident{NamePos: declPos, Name: p})
+		}
+		spec.TypeParams = &ast.FieldList{Opening: declPos, List: []*ast.Field{param}, Closing: declPos}
+	}
+
+	decl := &ast.GenDecl{
+		Doc:    declDoc,
+		TokPos: declPos,
+		Tok:    token.TYPE,
+		Specs:  []ast.Spec{spec},
+	}
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, decl); err != nil {
+		return "", fmt.Errorf("struct %s: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// setPositions moves every token of node to pos, so a type parsed on its own
+// prints on the line of its field.
+func setPositions(node ast.Node, pos token.Pos) {
+	posType := reflect.TypeOf(token.NoPos)
+	ast.Inspect(node, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		v := reflect.ValueOf(n).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Field(i); f.Type() == posType {
+				f.Set(reflect.ValueOf(pos))
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,57 @@
+package idlgen
+
+import (
+	"go/format"
+	"strings"
+	"testing"
+)
+
+func TestASTMatchesTemplate(t *testing.T) {
+	data := testIDL(`"types": [` + strings.Join([]string{
+		structDef("Pool",
+			`{"name": "authority", "docs": ["Signs withdrawals.", "Rotated by the admin."], "type": "pubkey"}`,
+			`{"name": "liquidity", "type": "u128"}`,
+			`{"name": "fee_bps", "type": {"option": "u16"}}`,
+			`{"name": "limit", "type": {"option": {"option": "u64"}}}`,
+			`{"name": "seeds", "type": {"array": ["u8", 32]}}`,
+			`{"name": "owners", "type": {"vec": "pubkey"}}`,
+			`{"name": "balances", "type": {"hashMap": ["string", "u64"]}}`,
+			`{"name": "pair", "type": {"tuple": ["u8", {"coption": "pubkey"}]}}`,
+			`{"name": "config", "type": {"defined": "Config"}}`,
+			`{"name": "cached", "type": "u64", "skip": true}`,
+			`{"name": "tier", "type": "u8", "default": 3}`,
+		),
+		structDef("Config", `{"name": "paused", "type": "bool"}`),
+		structDef("Empty"),
+	}, ", ") + `]`)
+	for _, opts := range []Options{
+		{},
+		{JSONTags: true, TagCase: TagCaseSnake},
+		{Prefix: "Alt", Equal: true, Validate: true, Assertions: true},
+	} {
+		template := mustGenerate(t, data, opts)
+		opts.AST = true
+		ast := mustGenerate(t, data, opts)
+		// Both outputs went through format.Source; formatting again must not
+		// change either, and they must agree byte for byte.
+		for name, code := range map[string]string{"template": template, "ast": ast} {
+			formatted, err := format.Source([]byte(code))
+			if err != nil {
+				t.Fatalf("%s output does not parse: %v", name, err)
+			}
+			if string(formatted) != code {
+				t.Errorf("%s output is not gofmt-clean", name)
+			}
+		}
+		if ast != template {
+			t.Errorf("options %+v: AST output differs from the template's:\n--- template\n%s\n--- ast\n%s", opts, template, ast)
+		}
+	}
+}
+
+func TestASTStructDeclRejectsInvalidType(t *testing.T) {
+	_, err := astStructDecl("TestPool", "TestPool represents the struct Pool.", nil, []astField{{Name: "Liquidity", Type: "map[string", Tag: "`bin:\"liquidity\"`"}})
+	if err == nil || !strings.Contains(err.Error(), `field Liquidity has invalid type "map[string"`) {
+		t.Errorf("got error %v, want the invalid field type", err)
+	}
+}
@@ -0,0 +1,174 @@
+package idlgen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// --- Batch Generation ---
+
+// GenerateDir generates bindings for every *.json IDL in idlDir, writing
+// <idl-name>.go files into outDir, or one package directory per program with
+// opts.PackagePerProgram. A failing IDL does not stop the rest; all failures
+// are returned together.
+func GenerateDir(idlDir, outDir string, opts Options) error {
+	paths, err := filepath.Glob(filepath.Join(idlDir, "*.json"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no IDL files found in %s", idlDir)
+	}
+
+	for _, dir := range []string{outDir, opts.ProtoPath, opts.TSPath} {
+		if dir == "" {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	// Each program gets its own <Prefix>Client; a shared name would collide.
+	if opts.ClientName != NoClient {
+		opts.ClientName = ""
+	}
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	// Generations are independent and CPU-bound in format.Source, so a
+	// bounded pool of workers handles them; results are indexed to keep the
+	// error order stable.
+	indexes := make(chan int)
+	results := make(chan batchResult)
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results <- generateBatchFile(paths[i], outDir, i, opts)
+			}
+		}()
+	}
+	go func() {
+		for i := range paths {
+			indexes <- i
+		}
+		close(indexes)
+		wg.Wait()
+		close(results)
+	}()
+
+	errs := make([]error, len(paths))
+	failed := 0
+	for r := range results {
+		if r.err != nil {
+			errs[r.index] = r.err
+			failed++
+			continue
+		}
+		if opts.Verbose {
+			log.Println("Generated", r.outPath)
+		}
+	}
+
+	if opts.Verbose {
+		log.Printf("Batch complete: %d succeeded, %d failed", len(paths)-failed, failed)
+	}
+
+	return errors.Join(errs...)
+}
+
+// batchResult is the outcome of generating one IDL in a batch.
+type batchResult struct {
+	index   int
+	outPath string
+	err     error
+}
+
+// generateBatchFile generates <idl-name>.go in outDir from idlPath, or
+// <pkg>/<pkg>.go with a doc.go when opts.PackagePerProgram is set, and
+// <idl-name>.proto and <idl-name>.ts in the opts.ProtoPath and opts.TSPath
+// directories.
+func generateBatchFile(idlPath, outDir string, index int, opts Options) batchResult {
+	name := strings.TrimSuffix(filepath.Base(idlPath), filepath.Ext(idlPath))
+	outPath := filepath.Join(outDir, name+".go")
+	if opts.ProtoPath != "" {
+		opts.ProtoPath = filepath.Join(opts.ProtoPath, name+".proto")
+	}
+	if opts.TSPath != "" {
+		opts.TSPath = filepath.Join(opts.TSPath, name+".ts")
+	}
+	if opts.PackagePerProgram {
+		programName, err := readProgramName(idlPath)
+		if err != nil {
+			return batchResult{index: index, err: fmt.Errorf("%s: %w", idlPath, err)}
+		}
+		if programName == "" || programName == "program" {
+			programName = name
+		}
+		pkg := packageName(programName)
+		pkgDir := filepath.Join(outDir, pkg)
+		if err := os.MkdirAll(pkgDir, 0755); err != nil {
+			return batchResult{index: index, err: fmt.Errorf("%s: %w", idlPath, err)}
+		}
+		doc := fmt.Sprintf("%s// Code generated by idlgen %s. DO NOT EDIT.\n\n// Package %s provides Go bindings for the %s Solana program.\npackage %s\n", banner(opts.Header), version(), pkg, programName, pkg)
+		if err := os.WriteFile(filepath.Join(pkgDir, "doc.go"), []byte(doc), 0644); err != nil {
+			return batchResult{index: index, err: fmt.Errorf("%s: %w", idlPath, err)}
+		}
+		opts.PackageName = pkg
+		outPath = filepath.Join(pkgDir, pkg+".go")
+	}
+	if err := GenerateWithOptions(idlPath, outPath, opts); err != nil {
+		return batchResult{index: index, err: fmt.Errorf("%s: %w", idlPath, err)}
+	}
+	return batchResult{index: index, outPath: outPath}
+}
+
+// readProgramName returns the program name declared by the IDL at idlPath,
+// at the top level for legacy IDLs and in the metadata block from Anchor 0.30.
+func readProgramName(idlPath string) (string, error) {
+	data, err := os.ReadFile(idlPath)
+	if err != nil {
+		return "", err
+	}
+	var idl struct {
+		Name     string      `json:"name"`
+		Metadata IdlMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(data, &idl); err != nil {
+		return "", fmt.Errorf("failed to parse IDL: %v", err)
+	}
+	if idl.Name == "" {
+		return idl.Metadata.Name, nil
+	}
+	return idl.Name, nil
+}
+
+// packageName converts a program name into a valid Go package name, e.g.
+// "token_swap" -> "tokenswap".
+func packageName(programName string) string {
+	pkg := strings.ToLower(toPascalCase(programName))
+	switch {
+	case pkg == "":
+		return "program"
+	case unicode.IsDigit(rune(pkg[0])):
+		return "p" + pkg
+	case token.IsKeyword(pkg):
+		return pkg + "pkg"
+	}
+	return pkg
+}
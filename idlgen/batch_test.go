@@ -0,0 +1,93 @@
+package idlgen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeIDLs writes each IDL to dir as <name>.json.
+func writeIDLs(t testing.TB, dir string, idls map[string][]byte) {
+	t.Helper()
+	for name, data := range idls {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFile returns the content of path, failing the test if it is missing.
+func readFile(t testing.TB, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestGenerateDirNoClient(t *testing.T) {
+	idlDir, outDir := t.TempDir(), t.TempDir()
+	writeIDLs(t, idlDir, map[string][]byte{"pool": structIDL("Pool", `{"name": "liquidity", "type": "u64"}`)})
+	if err := GenerateDir(idlDir, outDir, Options{ClientName: NoClient}); err != nil {
+		t.Fatal(err)
+	}
+	code := readFile(t, filepath.Join(outDir, "pool.go"))
+	assertNotContains(t, code, "solana-go/rpc\"", "Client struct")
+}
+
+func TestGenerateDirPackagePerProgram(t *testing.T) {
+	idlDir, outDir := t.TempDir(), t.TempDir()
+	writeIDLs(t, idlDir, map[string][]byte{
+		"swap":  []byte(`{"name": "token_swap", "address": "` + testAddress + `", "instructions": []}`),
+		"vault": []byte(`{"address": "` + testAddress + `", "instructions": []}`),
+		"lend":  []byte(`{"address": "` + testAddress + `", "metadata": {"name": "lending_pool"}, "instructions": []}`),
+	})
+	if err := GenerateDir(idlDir, outDir, Options{PackagePerProgram: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []struct{ path, pkg string }{
+		{"tokenswap/tokenswap.go", "tokenswap"},
+		{"tokenswap/doc.go", "tokenswap"},
+		{"vault/vault.go", "vault"},
+		{"vault/doc.go", "vault"},
+		{"lendingpool/lendingpool.go", "lendingpool"},
+	} {
+		code := readFile(t, filepath.Join(outDir, filepath.FromSlash(file.path)))
+		assertContains(t, code, "\npackage "+file.pkg+"\n")
+	}
+	assertContains(t, readFile(t, filepath.Join(outDir, "tokenswap", "doc.go")), "// Package tokenswap provides Go bindings for the token_swap Solana program.")
+}
+
+// BenchmarkGenerateDir compares serial and parallel batch generation over
+// 50 synthetic IDLs.
+func BenchmarkGenerateDir(b *testing.B) {
+	idlDir := b.TempDir()
+	idls := make(map[string][]byte, 50)
+	for i := 0; i < 50; i++ {
+		idls[fmt.Sprintf("program%d", i)] = testIDL(
+			`"instructions": [{"name": "deposit", "accounts": [{"name": "vault", "writable": true}], "args": [{"name": "amount", "type": "u64"}]}]`,
+			`"types": [`+structDef("Pool", `{"name": "liquidity", "type": "u64"}`, `{"name": "authority", "type": "pubkey"}`)+`]`,
+		)
+	}
+	writeIDLs(b, idlDir, idls)
+
+	for _, bench := range []struct {
+		name string
+		jobs int
+	}{
+		{"serial", 1},
+		{"parallel", runtime.NumCPU()},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			outDir := b.TempDir()
+			for i := 0; i < b.N; i++ {
+				if err := GenerateDir(idlDir, outDir, Options{Jobs: bench.jobs}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
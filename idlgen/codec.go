@@ -0,0 +1,175 @@
+package idlgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- Nested Option Codec ---
+
+// hasOption reports whether t is or contains an option or coption.
+func hasOption(t IdlType) bool {
+	found := false
+	walkType(t, func(t IdlType) {
+		if t.Option != nil || t.Coption != nil {
+			found = true
+		}
+	})
+	return found
+}
+
+// optionCodec renders MarshalWithEncoder and UnmarshalWithDecoder methods
+// for the struct typeName when one of its fields holds an option no struct
+// tag reaches (see nestedOption), such as option<option<u64>> or
+// vec<option<u8>>. The methods encode the fields in order and write the
+// presence prefix of every option level, which bin only writes for the
+// outermost. Structs without such fields get no methods ("").
+func optionCodec(typeName string, fields []IdlField, mapType func(IdlType) string) (string, error) {
+	nested := false
+	for _, f := range fields {
+		if !fieldSkipped(f.Attrs) && nestedOption(f.Type) {
+			nested = true
+		}
+	}
+	if !nested {
+		return "", nil
+	}
+
+	c := &codecWriter{mapType: mapType}
+	var enc, dec strings.Builder
+	for _, f := range fields {
+		if fieldSkipped(f.Attrs) {
+			continue
+		}
+		expr := "v." + toPascalCase(f.Name)
+		if err := c.encode(&enc, f.Type, expr, 0); err != nil {
+			return "", fmt.Errorf("struct %s: field %s: %v", typeName, f.Name, err)
+		}
+		if err := c.decode(&dec, f.Type, expr, 0); err != nil {
+			return "", fmt.Errorf("struct %s: field %s: %v", typeName, f.Name, err)
+		}
+	}
+
+	return fmt.Sprintf(`
+
+// MarshalWithEncoder encodes %[1]s with Borsh, writing the presence byte of
+// every option level; bin struct tags only reach the outermost.
+func (v %[1]s) MarshalWithEncoder(encoder *bin.Encoder) error {
+%[2]s	return nil
+}
+
+// UnmarshalWithDecoder decodes %[1]s from Borsh, reading the presence byte of
+// every option level.
+func (v *%[1]s) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	*v = %[1]s{}
+%[3]s	return nil
+}`, typeName, enc.String(), dec.String()), nil
+}
+
+// codecWriter writes the statements encoding and decoding one field.
+type codecWriter struct {
+	mapType func(IdlType) string
+}
+
+// encode writes statements encoding expr, of IDL type t.
+func (c *codecWriter) encode(b *strings.Builder, t IdlType, expr string, depth int) error {
+	if !hasOption(t) {
+		fmt.Fprintf(b, "if err := encoder.Encode(%s); err != nil {\nreturn err\n}\n", expr)
+		return nil
+	}
+	switch {
+	case t.Option != nil, t.Coption != nil:
+		inner, write := innerType(derefOption(t)), "WriteOption"
+		if t.Coption != nil {
+			write = "WriteCOption"
+		}
+		fmt.Fprintf(b, "if err := encoder.%s(%s != nil); err != nil {\nreturn err\n}\nif %s != nil {\n", write, expr, expr)
+		if err := c.encode(b, inner, "(*"+expr+")", depth); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+	case t.Vec != nil:
+		fmt.Fprintf(b, "if err := encoder.WriteUint32(uint32(len(%s)), bin.LE); err != nil {\nreturn err\n}\n", expr)
+		fallthrough
+	case t.Array != nil:
+		elem := fmt.Sprintf("elem%d", depth)
+		fmt.Fprintf(b, "for _, %s := range %s {\n", elem, expr)
+		if err := c.encode(b, elementType(t), elem, depth+1); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+	case t.Tuple != nil:
+		for i, elem := range t.Tuple {
+			if err := c.encode(b, innerType(elem), fmt.Sprintf("%s.Field%d", expr, i), depth); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("options inside maps are not supported")
+	}
+	return nil
+}
+
+// decode writes statements decoding into expr, of IDL type t.
+func (c *codecWriter) decode(b *strings.Builder, t IdlType, expr string, depth int) error {
+	if !hasOption(t) {
+		fmt.Fprintf(b, "if err := decoder.Decode(&%s); err != nil {\nreturn err\n}\n", expr)
+		return nil
+	}
+	switch {
+	case t.Option != nil, t.Coption != nil:
+		inner, read := innerType(derefOption(t)), "ReadOption"
+		if t.Coption != nil {
+			read = "ReadCOption"
+		}
+		fmt.Fprintf(b, "if present, err := decoder.%s(); err != nil {\nreturn err\n} else if present {\n%s = new(%s)\n", read, expr, c.mapType(inner))
+		if err := c.decode(b, inner, "(*"+expr+")", depth); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+	case t.Vec != nil:
+		fmt.Fprintf(b, "if n, err := decoder.ReadUint32(bin.LE); err != nil {\nreturn err\n} else {\n%s = make(%s, n)\n", expr, c.mapType(t))
+		if err := c.decodeElements(b, t, expr, depth); err != nil {
+			return err
+		}
+		b.WriteString("}\n")
+	case t.Array != nil:
+		return c.decodeElements(b, t, expr, depth)
+	case t.Tuple != nil:
+		for i, elem := range t.Tuple {
+			if err := c.decode(b, innerType(elem), fmt.Sprintf("%s.Field%d", expr, i), depth); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("options inside maps are not supported")
+	}
+	return nil
+}
+
+// decodeElements writes a loop decoding each element of the vec or array expr.
+func (c *codecWriter) decodeElements(b *strings.Builder, t IdlType, expr string, depth int) error {
+	i := fmt.Sprintf("i%d", depth)
+	fmt.Fprintf(b, "for %s := range %s {\n", i, expr)
+	if err := c.decode(b, elementType(t), fmt.Sprintf("%s[%s]", expr, i), depth+1); err != nil {
+		return err
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// derefOption returns the raw inner type of an option or coption.
+func derefOption(t IdlType) interface{} {
+	if t.Option != nil {
+		return *t.Option
+	}
+	return *t.Coption
+}
+
+// elementType returns the element type of a vec or array.
+func elementType(t IdlType) IdlType {
+	if t.Vec != nil {
+		return innerType(*t.Vec)
+	}
+	return innerType((*t.Array)[0])
+}
@@ -0,0 +1,306 @@
+package idlgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// --- IDL Diff ---
+
+// DiffIDL compares the IDLs at oldPath and newPath and describes, one line
+// per change, the instructions, accounts and types that were added, removed
+// or changed: args, fields and variants, their types, account flags and
+// discriminators. Identical IDLs report no changes.
+func DiffIDL(oldPath, newPath string) ([]string, error) {
+	if oldPath == "" || newPath == "" {
+		return nil, fmt.Errorf("old and new idl paths are required")
+	}
+	var idls [2]IDL
+	for i, p := range []string{oldPath, newPath} {
+		data, err := readInput(p)
+		if err != nil {
+			return nil, err
+		}
+		if idls[i], err = parseIDL(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+	return diffIDL(idls[0], idls[1]), nil
+}
+
+// diffIDL lists the changes from before to after.
+func diffIDL(before, after IDL) []string {
+	var changes []string
+	add := func(format string, args ...interface{}) {
+		changes = append(changes, fmt.Sprintf(format, args...))
+	}
+
+	oldInstrs := make(map[string]IdlInstruction)
+	for _, instr := range before.Instructions {
+		oldInstrs[instr.Name] = instr
+	}
+	newInstrs := make(map[string]bool)
+	for _, instr := range after.Instructions {
+		newInstrs[instr.Name] = true
+		prev, ok := oldInstrs[instr.Name]
+		if !ok {
+			add("+ instruction %s", instr.Name)
+			continue
+		}
+		owner := "instruction " + instr.Name
+		if d0, d1 := instructionDiscriminatorBytes(prev), instructionDiscriminatorBytes(instr); d0 != d1 {
+			add("~ %s: discriminator %s -> %s", owner, d0, d1)
+		}
+		changes = append(changes, diffFields(owner, "arg", prev.Args, instr.Args)...)
+		changes = append(changes, diffInstructionAccounts(owner, prev.Accounts, instr.Accounts)...)
+		if r0, r1 := returnString(prev.Returns), returnString(instr.Returns); r0 != r1 {
+			add("~ %s: returns %s -> %s", owner, r0, r1)
+		}
+	}
+	for _, instr := range before.Instructions {
+		if !newInstrs[instr.Name] {
+			add("- instruction %s", instr.Name)
+		}
+	}
+
+	oldAccounts := make(map[string]IdlAccountDefinition)
+	for _, a := range before.Accounts {
+		oldAccounts[a.Name] = a
+	}
+	newAccounts := make(map[string]bool)
+	for _, a := range after.Accounts {
+		newAccounts[a.Name] = true
+		prev, ok := oldAccounts[a.Name]
+		if !ok {
+			add("+ account %s", a.Name)
+			continue
+		}
+		owner := "account " + a.Name
+		if d0, d1 := accountDiscriminatorBytes(prev), accountDiscriminatorBytes(a); d0 != d1 {
+			add("~ %s: discriminator %s -> %s", owner, d0, d1)
+		}
+		changes = append(changes, diffFields(owner, "field", inlineFields(prev), inlineFields(a))...)
+	}
+	for _, a := range before.Accounts {
+		if !newAccounts[a.Name] {
+			add("- account %s", a.Name)
+		}
+	}
+
+	oldTypes := make(map[string]IdlTypeDefinition)
+	for _, t := range before.Types {
+		oldTypes[t.Name] = t
+	}
+	newTypes := make(map[string]bool)
+	for _, t := range after.Types {
+		newTypes[t.Name] = true
+		prev, ok := oldTypes[t.Name]
+		if !ok {
+			add("+ type %s", t.Name)
+			continue
+		}
+		owner := "type " + t.Name
+		if prev.Type.Kind != t.Type.Kind {
+			add("~ %s: kind %s -> %s", owner, prev.Type.Kind, t.Type.Kind)
+			continue
+		}
+		changes = append(changes, diffFields(owner, "field", prev.Type.Fields, t.Type.Fields)...)
+		changes = append(changes, diffVariants(owner, prev.Type.Variants, t.Type.Variants)...)
+	}
+	for _, t := range before.Types {
+		if !newTypes[t.Name] {
+			add("- type %s", t.Name)
+		}
+	}
+	return changes
+}
+
+// diffFields compares the named fields (or args) of owner. Fields that move
+// are reported too, since Borsh encodes them in order.
+func diffFields(owner, kind string, before, after []IdlField) []string {
+	var changes []string
+	oldIndex := make(map[string]int)
+	for i, f := range before {
+		oldIndex[f.Name] = i
+	}
+	newNames := make(map[string]bool)
+	for i, f := range after {
+		newNames[f.Name] = true
+		j, ok := oldIndex[f.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("~ %s: + %s %s %s", owner, kind, f.Name, idlTypeString(f.Type)))
+			continue
+		}
+		if t0, t1 := idlTypeString(before[j].Type), idlTypeString(f.Type); t0 != t1 {
+			changes = append(changes, fmt.Sprintf("~ %s: %s %s type %s -> %s", owner, kind, f.Name, t0, t1))
+		}
+		if i != j {
+			changes = append(changes, fmt.Sprintf("~ %s: %s %s moved from position %d to %d", owner, kind, f.Name, j, i))
+		}
+	}
+	for _, f := range before {
+		if !newNames[f.Name] {
+			changes = append(changes, fmt.Sprintf("~ %s: - %s %s %s", owner, kind, f.Name, idlTypeString(f.Type)))
+		}
+	}
+	return changes
+}
+
+// diffVariants compares the enum variants of owner by name and position.
+func diffVariants(owner string, before, after []IdlVariant) []string {
+	var changes []string
+	oldIndex := make(map[string]int)
+	for i, v := range before {
+		oldIndex[v.Name] = i
+	}
+	newNames := make(map[string]bool)
+	for i, v := range after {
+		newNames[v.Name] = true
+		j, ok := oldIndex[v.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("~ %s: + variant %s", owner, v.Name))
+			continue
+		}
+		if i != j {
+			changes = append(changes, fmt.Sprintf("~ %s: variant %s moved from index %d to %d", owner, v.Name, j, i))
+		}
+		changes = append(changes, diffFields(owner+" variant "+v.Name, "field", enumFields(before[j].Fields), enumFields(v.Fields))...)
+	}
+	for _, v := range before {
+		if !newNames[v.Name] {
+			changes = append(changes, fmt.Sprintf("~ %s: - variant %s", owner, v.Name))
+		}
+	}
+	return changes
+}
+
+// diffInstructionAccounts compares the accounts of an instruction, including
+// their position and writable, signer and optional flags.
+func diffInstructionAccounts(owner string, before, after []IdlAccount) []string {
+	var changes []string
+	oldIndex := make(map[string]int)
+	for i, a := range before {
+		oldIndex[a.Name] = i
+	}
+	newNames := make(map[string]bool)
+	for i, a := range after {
+		newNames[a.Name] = true
+		j, ok := oldIndex[a.Name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("~ %s: + account %s%s", owner, a.Name, accountFlags(a)))
+			continue
+		}
+		if f0, f1 := accountFlags(before[j]), accountFlags(a); f0 != f1 {
+			changes = append(changes, fmt.Sprintf("~ %s: account %s%s ->%s", owner, a.Name, f0, f1))
+		}
+		if i != j {
+			changes = append(changes, fmt.Sprintf("~ %s: account %s moved from position %d to %d", owner, a.Name, j, i))
+		}
+	}
+	for _, a := range before {
+		if !newNames[a.Name] {
+			changes = append(changes, fmt.Sprintf("~ %s: - account %s", owner, a.Name))
+		}
+	}
+	return changes
+}
+
+// accountFlags describes the flags of an instruction account, e.g. " (writable, signer)".
+func accountFlags(a IdlAccount) string {
+	var flags []string
+	if a.IsWritable {
+		flags = append(flags, "writable")
+	}
+	if a.IsSigner {
+		flags = append(flags, "signer")
+	}
+	if a.Optional {
+		flags = append(flags, "optional")
+	}
+	if len(flags) == 0 {
+		return " (readonly)"
+	}
+	return " (" + strings.Join(flags, ", ") + ")"
+}
+
+// instructionDiscriminatorBytes returns the hex discriminator of instr, as
+// given by the IDL or derived the way Anchor does.
+func instructionDiscriminatorBytes(instr IdlInstruction) string {
+	if len(instr.Discriminator) > 0 {
+		return discriminatorHex(instr.Discriminator)
+	}
+	return derivedDiscriminatorHex(DefaultInstructionNamespace, instructionDiscriminatorName(instr.Name, InstructionCaseSnake))
+}
+
+// accountDiscriminatorBytes returns the hex discriminator of a, as given by
+// the IDL or derived the way Anchor does.
+func accountDiscriminatorBytes(a IdlAccountDefinition) string {
+	if len(a.Discriminator) > 0 {
+		return discriminatorHex(a.Discriminator)
+	}
+	return derivedDiscriminatorHex("account", accountDiscriminatorName(a.Name, AccountCasePascal))
+}
+
+// discriminatorHex formats discriminator bytes as hex, e.g. "afaf6d1f0d989bed".
+func discriminatorHex(d []int) string {
+	var b strings.Builder
+	for _, v := range d {
+		fmt.Fprintf(&b, "%02x", v)
+	}
+	return b.String()
+}
+
+// derivedDiscriminatorHex is the hex of the discriminator manualDiscriminator derives.
+func derivedDiscriminatorHex(prefix, name string) string {
+	h := sha256.Sum256([]byte(prefix + ":" + name))
+	return hex.EncodeToString(h[:8])
+}
+
+// inlineFields returns the fields legacy IDLs declare inline on an account.
+func inlineFields(a IdlAccountDefinition) []IdlField {
+	if a.Type == nil {
+		return nil
+	}
+	return a.Type.Fields
+}
+
+// returnString describes an instruction return type, "nothing" when absent.
+func returnString(t *IdlType) string {
+	if t == nil {
+		return "nothing"
+	}
+	return idlTypeString(*t)
+}
+
+// idlTypeString renders t in IDL notation, e.g. "vec<pubkey>" or "[u8; 32]".
+func idlTypeString(t IdlType) string {
+	switch {
+	case t.Primitive != "":
+		return t.Primitive
+	case t.Defined != nil:
+		return *t.Defined
+	case t.Option != nil:
+		return "option<" + idlTypeString(innerType(*t.Option)) + ">"
+	case t.Coption != nil:
+		return "coption<" + idlTypeString(innerType(*t.Coption)) + ">"
+	case t.Vec != nil:
+		return "vec<" + idlTypeString(innerType(*t.Vec)) + ">"
+	case t.Map != nil:
+		return "map<" + idlTypeString(innerType((*t.Map)[0])) + ", " + idlTypeString(innerType((*t.Map)[1])) + ">"
+	case t.Array != nil:
+		size := symbolicArraySize((*t.Array)[1])
+		if n, ok := arraySize((*t.Array)[1]); ok {
+			size = fmt.Sprint(n)
+		}
+		return "[" + idlTypeString(innerType((*t.Array)[0])) + "; " + size + "]"
+	case t.Tuple != nil:
+		elems := make([]string, len(t.Tuple))
+		for i, elem := range t.Tuple {
+			elems[i] = idlTypeString(innerType(elem))
+		}
+		return "(" + strings.Join(elems, ", ") + ")"
+	}
+	return "unknown"
+}
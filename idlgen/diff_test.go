@@ -0,0 +1,39 @@
+package idlgen
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffIDL(t *testing.T) {
+	dir := t.TempDir()
+	deposit := `{"name": "deposit", "accounts": [], "args": [{"name": "amount", "type": "u64"}]}`
+	oldPath, newPath := filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	writeIDLs(t, dir, map[string][]byte{
+		"old": testIDL(
+			`"instructions": [`+deposit+`]`,
+			`"types": [`+structDef("Pool", `{"name": "liquidity", "type": "u64"}`)+`]`,
+		),
+		"new": testIDL(
+			`"instructions": [`+deposit+`, {"name": "withdraw", "accounts": [], "args": []}]`,
+			`"types": [`+structDef("Pool", `{"name": "liquidity", "type": "u128"}`)+`]`,
+		),
+	})
+
+	changes, err := DiffIDL(oldPath, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"+ instruction withdraw",
+		"~ type Pool: field liquidity type u64 -> u128",
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got changes %q, want %q", changes, want)
+	}
+
+	if changes, err := DiffIDL(oldPath, oldPath); err != nil || len(changes) != 0 {
+		t.Errorf("diffing an IDL with itself gave %q, %v", changes, err)
+	}
+}
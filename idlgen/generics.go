@@ -0,0 +1,232 @@
+package idlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --- Generics ---
+
+// monomorphize replaces every instantiation of a generic IDL type, such as
+// {"defined": {"name": "Wrapper", "generics": [{"kind": "type", "type": "u64"}]}},
+// with a reference to a concrete copy of the type ("WrapperU64") whose
+// generic parameters are substituted. The generic definitions themselves are
+// dropped since Borsh has no encoding for an uninstantiated type. IDLs
+// without generic types are returned unchanged.
+func monomorphize(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	types, _ := doc["types"].([]interface{})
+
+	generic := make(map[string]map[string]interface{})
+	var concrete []interface{}
+	for _, t := range types {
+		def, ok := t.(map[string]interface{})
+		if !ok {
+			concrete = append(concrete, t)
+			continue
+		}
+		if params, _ := def["generics"].([]interface{}); len(params) > 0 {
+			name, _ := def["name"].(string)
+			generic[name] = def
+			continue
+		}
+		concrete = append(concrete, t)
+	}
+	if len(generic) == 0 {
+		return data, nil
+	}
+
+	m := &monomorphizer{generic: generic, done: make(map[string]bool)}
+	for i, t := range concrete {
+		concrete[i] = m.substitute(t, nil)
+	}
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // instances are emitted in a stable order
+	for _, key := range keys {
+		if key != "types" {
+			doc[key] = m.substitute(doc[key], nil)
+		}
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	doc["types"] = append(concrete, m.pending...)
+	return json.Marshal(doc)
+}
+
+// monomorphizer tracks the instances created while substituting generics.
+type monomorphizer struct {
+	generic map[string]map[string]interface{} // generic type definitions by name
+	done    map[string]bool                   // instance names already created
+	pending []interface{}                     // instance definitions, in discovery order
+	err     error
+}
+
+// substitute copies node, replacing {"generic": "T"} with its binding in env
+// and generic instantiations with references to monomorphized instances.
+func (m *monomorphizer) substitute(node interface{}, env map[string]interface{}) interface{} {
+	switch n := node.(type) {
+	case []interface{}:
+		out := make([]interface{}, len(n))
+		for i, v := range n {
+			out[i] = m.substitute(v, env)
+		}
+		return out
+	case map[string]interface{}:
+		if name, ok := n["generic"].(string); ok && len(n) == 1 {
+			if bound, ok := env[name]; ok {
+				return bound
+			}
+			m.fail(fmt.Errorf("unbound generic parameter %q", name))
+			return node
+		}
+		if defined, ok := n["defined"].(map[string]interface{}); ok {
+			if args, _ := defined["generics"].([]interface{}); len(args) > 0 {
+				name, _ := defined["name"].(string)
+				return map[string]interface{}{"defined": map[string]interface{}{"name": m.instance(name, m.substitute(args, env).([]interface{}))}}
+			}
+		}
+		out := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			out[k] = m.substitute(v, env)
+		}
+		return out
+	default:
+		return node
+	}
+}
+
+// instance returns the name of the instance of generic type name for args,
+// queueing its definition the first time it is seen.
+func (m *monomorphizer) instance(name string, args []interface{}) string {
+	def, ok := m.generic[name]
+	if !ok {
+		m.fail(fmt.Errorf("type %q is instantiated with generics but is not generic", name))
+		return name
+	}
+	params, _ := def["generics"].([]interface{})
+	if len(params) != len(args) {
+		m.fail(fmt.Errorf("type %q takes %d generic arguments, got %d", name, len(params), len(args)))
+		return name
+	}
+
+	instName := name
+	env := make(map[string]interface{}, len(params))
+	for i, p := range params {
+		param, _ := p.(map[string]interface{})
+		arg, _ := args[i].(map[string]interface{})
+		paramName, _ := param["name"].(string)
+		if param["kind"] == "const" {
+			value := fmt.Sprint(arg["value"])
+			env[paramName] = json.Number(value)
+			instName += value
+		} else {
+			env[paramName] = arg["type"]
+			instName += typeSuffix(arg["type"])
+		}
+	}
+
+	if !m.done[instName] {
+		m.done[instName] = true
+		inst := make(map[string]interface{}, len(def))
+		for k, v := range def {
+			if k != "generics" {
+				inst[k] = v
+			}
+		}
+		inst["name"] = instName
+		inst["type"] = m.substitute(def["type"], env)
+		m.pending = append(m.pending, inst)
+	}
+	return instName
+}
+
+func (m *monomorphizer) fail(err error) {
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+// typeSuffix names a generic argument type for an instance name, e.g. "u64"
+// -> "U64" and {"vec": "pubkey"} -> "VecPubkey".
+func typeSuffix(t interface{}) string {
+	switch v := t.(type) {
+	case string:
+		return toPascalCase(v)
+	case map[string]interface{}:
+		for _, kind := range []string{"vec", "option", "coption"} {
+			if inner, ok := v[kind]; ok {
+				return toPascalCase(kind) + typeSuffix(inner)
+			}
+		}
+		if array, ok := v["array"].([]interface{}); ok && len(array) == 2 {
+			size := "N"
+			if n, ok := arraySize(array[1]); ok {
+				size = fmt.Sprint(n)
+			} else if name := symbolicArraySize(array[1]); name != "" {
+				size = toPascalCase(name)
+			}
+			return "Array" + typeSuffix(array[0]) + size
+		}
+		switch defined := v["defined"].(type) {
+		case string:
+			return toPascalCase(defined)
+		case map[string]interface{}:
+			name, _ := defined["name"].(string)
+			return toPascalCase(name)
+		}
+	}
+	return "T"
+}
+
+// keepsGenerics reports whether the generic types of the IDL in data can be
+// emitted with Go type parameters: every one must be a struct whose
+// parameters are all types. Go has no const generics or generic enums, so an
+// IDL using either is monomorphized as a whole.
+func keepsGenerics(data []byte) bool {
+	var idl IDL
+	if err := json.Unmarshal(data, &idl); err != nil {
+		return false
+	}
+	for _, t := range idl.Types {
+		for _, p := range t.Generics {
+			if p.Kind != "type" || t.Type.Kind != "struct" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// typeParams renders the type parameter list of a generic type, e.g.
+// "[T, U any]", or "" for a type without generics.
+func typeParams(params []IdlGenericParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(typeArgs(params), "]") + " any]"
+}
+
+// typeArgs renders the parameters of a generic type as the type arguments of
+// its receivers, e.g. "[T, U]", or "" for a type without generics.
+func typeArgs(params []IdlGenericParam) string {
+	if len(params) == 0 {
+		return ""
+	}
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
@@ -0,0 +1,137 @@
+package idlgen
+
+import "testing"
+
+// genericIDL instantiates the generic struct Wrapper<T> with u64.
+var genericIDL = testIDL(`"types": [
+	{"name": "Wrapper", "generics": [{"kind": "type", "name": "T"}], "type": {"kind": "struct", "fields": [
+		{"name": "value", "type": {"generic": "T"}}
+	]}},
+	{"name": "Holder", "type": {"kind": "struct", "fields": [
+		{"name": "wrapped", "type": {"defined": {"name": "Wrapper", "generics": [{"kind": "type", "type": "u64"}]}}}
+	]}}
+]`)
+
+func TestTypeParams(t *testing.T) {
+	data := testIDL(`"types": [
+		{"name": "Wrapper", "generics": [{"kind": "type", "name": "T"}], "type": {"kind": "struct", "fields": [
+			{"name": "value", "type": {"generic": "T"}}
+		]}},
+		{"name": "Pair", "generics": [{"kind": "type", "name": "K"}, {"kind": "type", "name": "V"}], "type": {"kind": "struct", "fields": [
+			{"name": "key", "type": {"generic": "K"}},
+			{"name": "value", "type": {"option": {"generic": "V"}}}
+		]}},
+		{"name": "Holder", "type": {"kind": "struct", "fields": [
+			{"name": "wrapped", "type": {"defined": {"name": "Wrapper", "generics": [{"kind": "type", "type": "u64"}]}}},
+			{"name": "pair", "type": {"defined": {"name": "Pair", "generics": [{"kind": "type", "type": "pubkey"}, {"kind": "type", "type": {"vec": "u8"}}]}}}
+		]}}
+	]`)
+	opts := Options{GoVersion: "1.22", Constructors: true, OptionHelpers: true, Equal: true, Validate: true, Assertions: true}
+	code := mustGenerate(t, data, opts)
+	if got := fieldType(t, code, "TestHolder", "Wrapped"); got != "TestWrapper[uint64]" {
+		t.Errorf("Wrapper<u64> maps to %s, want TestWrapper[uint64]", got)
+	}
+	if got := fieldType(t, code, "TestHolder", "Pair"); got != "TestPair[solana.PublicKey, []uint8]" {
+		t.Errorf("Pair<pubkey, vec<u8>> maps to %s, want TestPair[solana.PublicKey, []uint8]", got)
+	}
+	assertContains(t, code,
+		"type TestWrapper[T any] struct {",
+		"type TestPair[K, V any] struct {",
+		"func NewTestPair[K, V any](",
+		"func (v TestPair[K, V]) HasValue() bool",
+		"func (v *TestWrapper[T]) UnmarshalBinary(data []byte) error",
+	)
+	assertNotContains(t, code, "TestWrapperU64", "(*TestWrapper[T])(nil)")
+
+	opts.AST = true
+	if ast := mustGenerate(t, data, opts); ast != code {
+		t.Error("-ast output differs from the template's")
+	}
+
+	runGenerated(t, code, `package bindings
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestGenericRoundTrip(t *testing.T) {
+	value := []byte{1, 2}
+	h := NewTestHolder(NewTestWrapper[uint64](7), NewTestPair(solana.SystemProgramID, &value))
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// u64, then the pubkey, the option flag, the vec length and its bytes.
+	if len(data) != 8+32+1+4+2 {
+		t.Fatalf("encoded %d bytes", len(data))
+	}
+	var got TestHolder
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(&h) || !got.Pair.HasValue() {
+		t.Errorf("decoded %+v, want %+v", got, h)
+	}
+	if err := got.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+`)
+}
+
+func TestMonomorphize(t *testing.T) {
+	code := mustGenerate(t, genericIDL, Options{GoVersion: "1.17"})
+	if got := fieldType(t, code, "TestHolder", "Wrapped"); got != "TestWrapperU64" {
+		t.Errorf("Wrapper<u64> maps to %s, want TestWrapperU64", got)
+	}
+	if got := fieldType(t, code, "TestWrapperU64", "Value"); got != "uint64" {
+		t.Errorf("TestWrapperU64.Value has type %s, want uint64", got)
+	}
+	assertNotContains(t, code, "type TestWrapper struct", "[T any]")
+}
+
+func TestMonomorphizeConstGenerics(t *testing.T) {
+	data := testIDL(`"types": [
+		{"name": "Buffer", "generics": [{"kind": "const", "name": "N", "type": "usize"}], "type": {"kind": "struct", "fields": [
+			{"name": "data", "type": {"array": ["u8", {"generic": "N"}]}}
+		]}},
+		{"name": "Holder", "type": {"kind": "struct", "fields": [
+			{"name": "wrapped", "type": {"defined": {"name": "Wrapper", "generics": [{"kind": "type", "type": "u64"}]}}},
+			{"name": "buffer", "type": {"defined": {"name": "Buffer", "generics": [{"kind": "const", "value": "4"}]}}}
+		]}},
+		{"name": "Wrapper", "generics": [{"kind": "type", "name": "T"}], "type": {"kind": "struct", "fields": [
+			{"name": "value", "type": {"generic": "T"}}
+		]}}
+	]`)
+	// Go has no const generics, so the whole IDL is monomorphized.
+	code := mustGenerate(t, data, Options{GoVersion: "1.22"})
+	if got := fieldType(t, code, "TestHolder", "Buffer"); got != "TestBuffer4" {
+		t.Errorf("Buffer<4> maps to %s, want TestBuffer4", got)
+	}
+	if got := fieldType(t, code, "TestHolder", "Wrapped"); got != "TestWrapperU64" {
+		t.Errorf("Wrapper<u64> maps to %s, want TestWrapperU64", got)
+	}
+	assertNotContains(t, code, "[T any]", "[N any]")
+}
+
+func TestMonomorphizeForGo117(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "deposit", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [], "args": [
+		{"name": "wrapped", "type": {"defined": {"name": "Wrapper", "generics": [{"kind": "type", "type": "u64"}]}}}
+	]}]`, `"types": [
+		{"name": "Wrapper", "generics": [{"kind": "type", "name": "T"}], "type": {"kind": "struct", "fields": [
+			{"name": "value", "type": {"generic": "T"}}
+		]}}
+	]`)
+	code := mustGenerate(t, data, Options{GoVersion: "1.17"})
+	if got := fieldType(t, code, "TestDepositArgs", "Wrapped"); got != "TestWrapperU64" {
+		t.Errorf("Wrapper<u64> maps to %s, want TestWrapperU64", got)
+	}
+	assertContains(t, code, "type TestWrapperU64 struct {", "*(*[8]byte)(TestDepositDiscriminator)")
+	assertNotContains(t, code, "[T any]", "[T]", "[8]byte(TestDepositDiscriminator)")
+
+	if _, err := generate(data, Options{GoVersion: "1.16"}); err == nil {
+		t.Error("generate accepted go1.16")
+	}
+}
@@ -2,15 +2,33 @@ package idlgen
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go/format"
+	"go/scanner"
+	"go/token"
+	"io"
 	"log"
+	"math"
+	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
+	"unicode"
 )
 
 // --- IDL Data Structures ---
@@ -24,6 +42,20 @@ type IDL struct {
 	Accounts     []IdlAccountDefinition `json:"accounts"`
 	Types        []IdlTypeDefinition    `json:"types"`
 	Errors       []IdlError             `json:"errors"`
+	Constants    []IdlConst             `json:"constants"`
+	Events       []IdlEvent             `json:"events"`
+	Metadata     IdlMetadata            `json:"metadata"`
+}
+
+// IdlMetadata holds the IDL metadata block. Legacy (pre-0.30) IDLs store the
+// program address here instead of at the top level, and the name and version
+// at the top level instead.
+type IdlMetadata struct {
+	Address    string `json:"address"`
+	Name       string `json:"name,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Spec       string `json:"spec,omitempty"`
+	Repository string `json:"repository,omitempty"`
 }
 
 // IdlInstruction represents a specific instruction definition.
@@ -33,24 +65,45 @@ type IdlInstruction struct {
 	Discriminator []int        `json:"discriminator"`
 	Args          []IdlField   `json:"args"`
 	Accounts      []IdlAccount `json:"accounts"`
+	Returns       *IdlType     `json:"returns,omitempty"`
 }
 
 // IdlAccountDefinition represents the definition of an account (mainly for discriminators).
+// Older IDLs carry the account layout inline under "type" instead of in the Types section.
 type IdlAccountDefinition struct {
 	Name          string `json:"name"`
 	Discriminator []int  `json:"discriminator"`
+	Type          *struct {
+		Kind   string     `json:"kind"`
+		Fields []IdlField `json:"fields,omitempty"`
+	} `json:"type,omitempty"`
+}
+
+// IdlEvent represents an event emitted by the program. Newer IDLs only carry
+// the discriminator and define the layout in the Types section.
+type IdlEvent struct {
+	Name          string     `json:"name"`
+	Discriminator []int      `json:"discriminator"`
+	Fields        []IdlField `json:"fields,omitempty"`
 }
 
 // IdlTypeDefinition represents user-defined types (structs or enums).
 type IdlTypeDefinition struct {
-	Name string `json:"name"`
-	Type struct {
+	Name     string            `json:"name"`
+	Generics []IdlGenericParam `json:"generics,omitempty"`
+	Type     struct {
 		Kind     string       `json:"kind"` // "struct" or "enum"
 		Fields   []IdlField   `json:"fields,omitempty"`
 		Variants []IdlVariant `json:"variants,omitempty"`
 	} `json:"type"`
 }
 
+// IdlGenericParam represents a generic parameter of a type definition.
+type IdlGenericParam struct {
+	Kind string `json:"kind"` // "type" or "const"
+	Name string `json:"name"`
+}
+
 // IdlVariant represents a specific variant within an Enum.
 type IdlVariant struct {
 	Name   string         `json:"name"`
@@ -59,8 +112,10 @@ type IdlVariant struct {
 
 // IdlEnumField represents a field within an Enum variant.
 type IdlEnumField struct {
-	Name string
-	Type IdlType
+	Name  string
+	Docs  []string
+	Type  IdlType
+	Attrs []string
 }
 
 // UnmarshalJSON handles custom deserialization for enum fields (named structs or tuple strings).
@@ -79,15 +134,33 @@ func (ef *IdlEnumField) UnmarshalJSON(data []byte) error {
 	_, hasName := m["name"]
 	_, hasType := m["type"]
 	if hasName && hasType {
+		// A plain struct, since IdlField decodes through this method.
 		var f struct {
-			Name string  `json:"name"`
-			Type IdlType `json:"type"`
+			Name    string          `json:"name"`
+			Docs    []string        `json:"docs"`
+			Type    IdlType         `json:"type"`
+			Attrs   []string        `json:"attrs"`
+			Skip    bool            `json:"skip"`
+			Default json.RawMessage `json:"default"`
 		}
 		if err := json.Unmarshal(data, &f); err != nil {
 			return err
 		}
 		ef.Name = f.Name
+		ef.Docs = f.Docs
 		ef.Type = f.Type
+		ef.Attrs = f.Attrs
+		// The skip and default keys are shorthands for the attrs.
+		if f.Skip {
+			ef.Attrs = append(ef.Attrs, "skip")
+		}
+		if len(f.Default) > 0 {
+			var value bytes.Buffer
+			if err := json.Compact(&value, f.Default); err != nil {
+				return err
+			}
+			ef.Attrs = append(ef.Attrs, "default = "+value.String())
+		}
 		return nil
 	}
 	var t IdlType
@@ -98,17 +171,70 @@ func (ef *IdlEnumField) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// IdlField represents a standard field with a name and a type.
+// IdlField represents a standard field with a name and a type. Tuple structs
+// list bare types instead, which decode as fields without a name. Attrs are
+// serialization hints such as "skip" (the field is not encoded) and
+// "default = <value>".
 type IdlField struct {
-	Name string  `json:"name"`
-	Type IdlType `json:"type"`
+	Name  string   `json:"name"`
+	Docs  []string `json:"docs"`
+	Type  IdlType  `json:"type"`
+	Attrs []string `json:"attrs,omitempty"`
+}
+
+// UnmarshalJSON handles both named fields and the bare types of tuple structs.
+func (f *IdlField) UnmarshalJSON(data []byte) error {
+	var ef IdlEnumField
+	if err := json.Unmarshal(data, &ef); err != nil {
+		return err
+	}
+	*f = IdlField(ef)
+	return nil
 }
 
-// IdlAccount represents an account used in an instruction.
+// IdlAccount represents an account used in an instruction. An entry with its
+// own Accounts is a group of accounts rather than an account itself.
 type IdlAccount struct {
-	Name       string `json:"name"`
-	IsWritable bool   `json:"writable"`
-	IsSigner   bool   `json:"signer"`
+	Name       string       `json:"name"`
+	IsWritable bool         `json:"writable"`
+	IsSigner   bool         `json:"signer"`
+	Optional   bool         `json:"optional"`
+	Address    string       `json:"address,omitempty"` // fixed address, e.g. of a program account
+	Pda        *IdlPda      `json:"pda,omitempty"`
+	Accounts   []IdlAccount `json:"accounts,omitempty"`
+}
+
+// UnmarshalJSON reads the writable/signer/optional flags of Anchor 0.30 IDLs
+// as well as the isMut/isSigner/isOptional spellings of older ones.
+func (a *IdlAccount) UnmarshalJSON(data []byte) error {
+	type plain IdlAccount // without this method, so decoding does not recurse
+	var v struct {
+		plain
+		IsMut          bool `json:"isMut"`
+		LegacySigner   bool `json:"isSigner"`
+		LegacyOptional bool `json:"isOptional"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*a = IdlAccount(v.plain)
+	a.IsWritable = a.IsWritable || v.IsMut
+	a.IsSigner = a.IsSigner || v.LegacySigner
+	a.Optional = a.Optional || v.LegacyOptional
+	return nil
+}
+
+// IdlPda describes how the address of a program-derived account is computed.
+type IdlPda struct {
+	Seeds   []IdlSeed `json:"seeds"`
+	Program *IdlSeed  `json:"program,omitempty"`
+}
+
+// IdlSeed represents a single PDA seed: constant bytes, an account reference or an arg reference.
+type IdlSeed struct {
+	Kind  string      `json:"kind"` // "const", "account" or "arg"
+	Value interface{} `json:"value,omitempty"`
+	Path  string      `json:"path,omitempty"`
 }
 
 // IdlError represents a custom program error.
@@ -118,6 +244,13 @@ type IdlError struct {
 	Message string `json:"msg"`
 }
 
+// IdlConst represents a constant published by the program.
+type IdlConst struct {
+	Name  string  `json:"name"`
+	Type  IdlType `json:"type"`
+	Value string  `json:"value"`
+}
+
 // IdlType represents polymorphic data types.
 type IdlType struct {
 	Primitive string
@@ -126,6 +259,10 @@ type IdlType struct {
 	Vec       *interface{}
 	Option    *interface{}
 	Coption   *interface{}
+	Map       *[2]interface{} // key and value types of a hashMap or btreeMap
+	Tuple     []interface{}   // element types of an inline tuple
+	Generic   string          // generic parameter, e.g. "T"
+	Generics  []interface{}   // type arguments of a generic Defined type
 }
 
 // UnmarshalJSON handles polymorphism for IDL types.
@@ -146,9 +283,18 @@ func (t *IdlType) UnmarshalJSON(data []byte) error {
 	if definedObj, ok := obj["defined"].(map[string]interface{}); ok {
 		if name, ok := definedObj["name"].(string); ok {
 			t.Defined = &name
+			args, _ := definedObj["generics"].([]interface{})
+			for _, arg := range args {
+				a, _ := arg.(map[string]interface{})
+				t.Generics = append(t.Generics, a["type"])
+			}
 			return nil
 		}
 	}
+	if generic, ok := obj["generic"].(string); ok {
+		t.Generic = generic
+		return nil
+	}
 	if array, ok := obj["array"].([]interface{}); ok && len(array) == 2 {
 		t.Array = &[2]interface{}{array[0], array[1]}
 		return nil
@@ -157,20 +303,275 @@ func (t *IdlType) UnmarshalJSON(data []byte) error {
 		t.Vec = &vec
 		return nil
 	}
+	if tuple, ok := obj["tuple"].([]interface{}); ok {
+		t.Tuple = tuple
+		return nil
+	}
+	for _, kind := range []string{"hashMap", "btreeMap"} {
+		if kv, ok := obj[kind].([]interface{}); ok && len(kv) == 2 {
+			t.Map = &[2]interface{}{kv[0], kv[1]}
+			return nil
+		}
+	}
 	if option, ok := obj["option"]; ok {
 		t.Option = &option
 		return nil
 	}
+	if coption, ok := obj["coption"]; ok {
+		t.Coption = &coption
+		return nil
+	}
 	return nil
 }
 
+// innerType decodes the raw JSON value of a composite type (the element of a
+// vec, option or array) into an IdlType, so both the string and object forms
+// of "defined" resolve the same way at any nesting depth.
+func innerType(v interface{}) IdlType {
+	var inner IdlType
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return inner
+	}
+	_ = json.Unmarshal(raw, &inner)
+	return inner
+}
+
 // --- Helper Functions ---
 
-// toPascalCase converts a string to PascalCase.
+// isPubkey reports whether t is a public key, under either the current
+// ("pubkey") or legacy ("publicKey") spelling.
+func isPubkey(t IdlType) bool {
+	return t.Primitive == "pubkey" || t.Primitive == "publicKey"
+}
+
+// nameTupleFields names the positional fields of tuple structs and tuple enum
+// variants "field0", "field1", ... so they render as Field0, Field1, ... in
+// their Borsh order.
+func nameTupleFields(idl *IDL) {
+	for i := range idl.Types {
+		t := &idl.Types[i].Type
+		for j := range t.Fields {
+			if t.Fields[j].Name == "" {
+				t.Fields[j].Name = fmt.Sprintf("field%d", j)
+			}
+		}
+		for _, v := range t.Variants {
+			for j := range v.Fields {
+				if v.Fields[j].Name == "" {
+					v.Fields[j].Name = fmt.Sprintf("field%d", j)
+				}
+			}
+		}
+	}
+}
+
+// flattenAccounts expands nested account groups in place of the group entry,
+// naming each member "group.member" so the order matches Anchor's AccountMeta layout.
+func flattenAccounts(accounts []IdlAccount, group string) []IdlAccount {
+	var flat []IdlAccount
+	for _, a := range accounts {
+		if group != "" {
+			a.Name = group + "." + a.Name
+		}
+		if len(a.Accounts) > 0 {
+			flat = append(flat, flattenAccounts(a.Accounts, a.Name)...)
+			continue
+		}
+		flat = append(flat, a)
+	}
+	return flat
+}
+
+// hasOptionalAccounts reports whether any of the accounts may be omitted.
+func hasOptionalAccounts(accounts []IdlAccount) bool {
+	for _, a := range accounts {
+		if a.Optional {
+			return true
+		}
+	}
+	return false
+}
+
+// SPL token program addresses recognized by Options.SPLHelpers.
+const (
+	tokenProgramAddress     = "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"
+	token2022ProgramAddress = "TokenzQdBNbLqP5VEhdkAS6EHFLHpwJx2SLw8jXu5GFn"
+)
+
+// isTokenProgram reports whether an instruction account is the SPL token
+// program, by its fixed address or its name.
+func isTokenProgram(a IdlAccount) bool {
+	return a.Address == tokenProgramAddress || a.Address == token2022ProgramAddress || toSnakeCase(a.Name) == "token_program"
+}
+
+// isTokenAccount reports whether an instruction account is named as a token
+// account, e.g. user_token_account or vaultTokenAccount.
+func isTokenAccount(name string) bool {
+	return strings.HasSuffix(toPascalCase(name), "TokenAccount")
+}
+
+// hasTokenAccounts reports whether any of the accounts is a token account.
+func hasTokenAccounts(accounts []IdlAccount) bool {
+	for _, a := range accounts {
+		if isTokenAccount(a.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// usesSPLToken reports whether the instructions of idl take the token program
+// and token accounts.
+func usesSPLToken(idl IDL) bool {
+	program, accounts := false, false
+	for _, instr := range idl.Instructions {
+		for _, a := range instr.Accounts {
+			program = program || isTokenProgram(a)
+		}
+		accounts = accounts || hasTokenAccounts(instr.Accounts)
+	}
+	return program && accounts
+}
+
+// isComplexEnum reports whether any variant of the enum carries fields.
+func isComplexEnum(variants []IdlVariant) bool {
+	for _, v := range variants {
+		if len(v.Fields) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// toPascalCase converts a snake_case or camelCase string to PascalCase.
+// Words start after separators and digits; existing capitals (including
+// acronyms) are kept as-is, so "initializeAccount3" and "initialize_account_3"
+// both become "InitializeAccount3".
 func toPascalCase(s string) string {
-	s = strings.ReplaceAll(s, "_", " ")
-	s = strings.Title(s)
-	return strings.ReplaceAll(s, " ", "")
+	var b strings.Builder
+	upperNext := true
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			if upperNext {
+				r = unicode.ToUpper(r)
+			}
+			upperNext = false
+			b.WriteRune(r)
+		case unicode.IsDigit(r):
+			upperNext = true
+			b.WriteRune(r)
+		default:
+			upperNext = true
+		}
+	}
+	return b.String()
+}
+
+// toCamelCase converts a string to camelCase, avoiding Go keywords.
+func toCamelCase(s string) string {
+	p := toPascalCase(s)
+	if p == "" {
+		return p
+	}
+	c := strings.ToLower(p[:1]) + p[1:]
+	if token.IsKeyword(c) {
+		c += "_"
+	}
+	return c
+}
+
+// toSnakeCase converts a string to snake_case.
+func toSnakeCase(s string) string {
+	runes := []rune(toPascalCase(s))
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Tag casings for Options.TagCase.
+const (
+	TagCaseRaw   = "raw"
+	TagCaseSnake = "snake"
+	TagCaseCamel = "camel"
+)
+
+// tagName converts an IDL field name to the casing used in bin tags.
+func tagName(name, tagCase string) string {
+	switch tagCase {
+	case TagCaseSnake:
+		return toSnakeCase(name)
+	case TagCaseCamel:
+		p := toPascalCase(name)
+		if p == "" {
+			return p
+		}
+		return strings.ToLower(p[:1]) + p[1:]
+	default:
+		return name
+	}
+}
+
+// Account name casings for Options.AccountNameCase.
+const (
+	AccountCasePascal = "pascal"
+	AccountCaseSnake  = "snake"
+	AccountCaseRaw    = "raw"
+)
+
+// accountDiscriminatorName returns the account name hashed into a derived
+// account discriminator. Anchor hashes the Rust struct name, so the default
+// is PascalCase whatever the IDL version; legacy IDLs that spell names
+// differently would otherwise decode nothing.
+func accountDiscriminatorName(name, casing string) string {
+	switch casing {
+	case AccountCaseSnake:
+		return toSnakeCase(name)
+	case AccountCaseRaw:
+		return name
+	default:
+		return toPascalCase(name)
+	}
+}
+
+// Instruction name casings for Options.InstrNameCase.
+const (
+	InstructionCaseSnake = "snake"
+	InstructionCaseCamel = "camel"
+	InstructionCaseRaw   = "raw"
+)
+
+// DefaultInstructionNamespace is the namespace Anchor prefixes to instruction
+// names before hashing them into discriminators.
+const DefaultInstructionNamespace = "global"
+
+// instructionDiscriminatorName returns the instruction name hashed into a
+// derived instruction discriminator. Anchor hashes the snake_case name of the
+// Rust handler, so the default converts the camelCase names of legacy IDLs;
+// names already in lower case are kept as they are.
+func instructionDiscriminatorName(name, casing string) string {
+	switch casing {
+	case InstructionCaseCamel:
+		return tagName(name, TagCaseCamel)
+	case InstructionCaseRaw:
+		return name
+	default:
+		if name == strings.ToLower(name) {
+			return name
+		}
+		return toSnakeCase(name)
+	}
 }
 
 // intSliceToBytesLiteral converts an int slice to a Go byte slice string.
@@ -185,190 +586,2542 @@ func intSliceToBytesLiteral(nums []int) string {
 	return strings.Join(parts, ", ")
 }
 
+// Discriminator modes for Options.DiscriminatorMode.
+const (
+	DiscriminatorModeAnchor = "anchor" // 8-byte sha256 prefixes
+	DiscriminatorModeU32LE  = "u32le"  // 4-byte little-endian instruction tags
+)
+
+// u32leDiscriminator returns the 4-byte little-endian encoding of tag.
+func u32leDiscriminator(tag uint32) []int {
+	return []int{int(tag & 0xff), int(tag >> 8 & 0xff), int(tag >> 16 & 0xff), int(tag >> 24)}
+}
+
+// discriminatorLen returns the length of an IDL discriminator, or 8 for the
+// sha256-derived discriminator generated when none is provided.
+func discriminatorLen(d []int) int {
+	if len(d) == 0 {
+		return 8
+	}
+	return len(d)
+}
+
 // manualDiscriminator generates a discriminator hash if none is provided.
 func manualDiscriminator(prefix, name string) string {
 	h := sha256.Sum256([]byte(prefix + ":" + name))
 	return intSliceToBytesLiteral([]int{int(h[0]), int(h[1]), int(h[2]), int(h[3]), int(h[4]), int(h[5]), int(h[6]), int(h[7])})
 }
 
-// --- Generator ---
-
-// Generate processes the IDL and outputs the Go binding file.
-func Generate(idlPath, outPath, pkgName, clientName *string, verbose bool) error {
-	if *idlPath == "" || *outPath == "" {
-		return fmt.Errorf("idl and out paths are required")
+// arraySize returns the length of an array type given as a literal: a JSON
+// number, a numeric string, or an object wrapping either under "value" (as in
+// {"kind": "const", "value": "32"}). Other sizes report false.
+func arraySize(size interface{}) (int, bool) {
+	switch s := size.(type) {
+	case float64:
+		if s >= 0 && s == math.Trunc(s) && s <= math.MaxInt32 {
+			return int(s), true
+		}
+	case json.Number:
+		return arraySize(s.String())
+	case string:
+		if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+			return n, true
+		}
+	case map[string]interface{}:
+		if v, ok := s["value"]; ok {
+			return arraySize(v)
+		}
 	}
+	return 0, false
+}
 
-	data, err := os.ReadFile(*idlPath)
-	if err != nil {
-		return err
+// symbolicArraySize returns the name used as the size of an array type when
+// it is not a numeric literal, or "" if the size is numeric or unnamed.
+func symbolicArraySize(size interface{}) string {
+	if _, ok := arraySize(size); ok {
+		return ""
 	}
-
-	var idl IDL
-	if err := json.Unmarshal(data, &idl); err != nil {
-		return fmt.Errorf("failed to parse IDL: %v", err)
+	if obj, ok := size.(map[string]interface{}); ok {
+		if name, ok := obj["generic"].(string); ok {
+			return name
+		}
+	}
+	t := innerType(size)
+	if t.Defined != nil {
+		return *t.Defined
 	}
+	return t.Primitive
+}
 
-	if idl.Name == "" || idl.Name == "program" {
-		fileName := filepath.Base(*idlPath)
-		ext := filepath.Ext(fileName)
-		idl.Name = strings.TrimSuffix(fileName, ext)
+// sizeNote returns a trailing comment naming any symbolic array sizes within t
+// that could not be resolved to a known constant.
+func sizeNote(t IdlType, known map[string]string) string {
+	var names []string
+	walkType(t, func(t IdlType) {
+		if t.Array == nil {
+			return
+		}
+		if name := symbolicArraySize((*t.Array)[1]); name != "" && known[name] == "" {
+			names = append(names, name)
+		}
+	})
+	if len(names) == 0 {
+		return ""
 	}
+	return " // size: " + strings.Join(names, ", ")
+}
 
-	prefix := toPascalCase(idl.Name)
+// primitiveSize returns the Borsh size of a fixed-size primitive, or -1 for
+// strings, bytes and unknown primitives.
+func primitiveSize(primitive string) int {
+	switch primitive {
+	case "bool", "u8", "i8":
+		return 1
+	case "u16", "i16":
+		return 2
+	case "u32", "i32", "f32":
+		return 4
+	case "u64", "i64", "f64":
+		return 8
+	case "u128", "i128":
+		return 16
+	case "u256", "i256":
+		return 32
+	case "pubkey", "publicKey":
+		return 32
+	default:
+		return -1
+	}
+}
 
-	if *clientName == "" {
-		*clientName = prefix + "Client"
+// enumFields converts enum variant fields to plain fields.
+func enumFields(fields []IdlEnumField) []IdlField {
+	out := make([]IdlField, len(fields))
+	for i, f := range fields {
+		out[i] = IdlField(f)
 	}
+	return out
+}
 
-	var mapType func(t IdlType) string
-	mapType = func(t IdlType) string {
-		if t.Primitive != "" {
-			switch t.Primitive {
-			case "bool":
-				return "bool"
-			case "u8", "i8":
-				return "uint8"
-			case "u16":
-				return "uint16"
-			case "i16":
-				return "int16"
-			case "u32":
-				return "uint32"
-			case "i32":
-				return "int32"
-			case "u64":
-				return "uint64"
-			case "i64":
-				return "int64"
-			case "u128":
-				return "bin.Uint128"
-			case "i128":
-				return "bin.Int128"
-			case "bytes":
-				return "[]byte"
-			case "string":
-				return "string"
-			case "pubkey", "publicKey":
-				return "solana.PublicKey"
-			default:
-				return "interface{}"
-			}
-		}
-		if t.Defined != nil {
-			return prefix + toPascalCase(*t.Defined)
-		}
-		if t.Option != nil {
-			innerBytes, _ := json.Marshal(*t.Option)
-			var inner IdlType
-			_ = json.Unmarshal(innerBytes, &inner)
-			return "*" + mapType(inner)
+// fieldSkipped reports whether attrs mark a field as not encoded, e.g. a
+// client-only computed field.
+func fieldSkipped(attrs []string) bool {
+	for _, attr := range attrs {
+		if attr == "skip" {
+			return true
 		}
-		if t.Vec != nil {
-			innerBytes, _ := json.Marshal(*t.Vec)
-			var inner IdlType
-			_ = json.Unmarshal(innerBytes, &inner)
-			return "[]" + mapType(inner)
+	}
+	return false
+}
+
+// fieldDefault describes the default value attrs give a field: the value of
+// "default = <value>", "its zero value" for a bare "default", or "" when
+// there is none.
+func fieldDefault(attrs []string) string {
+	for _, attr := range attrs {
+		if attr == "default" {
+			return "its zero value"
 		}
-		if t.Array != nil {
-			innerBytes, _ := json.Marshal((*t.Array)[0])
-			var inner IdlType
-			_ = json.Unmarshal(innerBytes, &inner)
-			size := (*t.Array)[1]
-			return fmt.Sprintf("[%d]%s", int(size.(float64)), mapType(inner))
+		value, ok := strings.CutPrefix(attr, "default")
+		if value = strings.TrimSpace(value); ok && strings.HasPrefix(value, "=") {
+			return strings.TrimSpace(value[1:])
 		}
-		return "interface{}"
 	}
+	return ""
+}
 
-	funcMap := template.FuncMap{
-		"toPascalCase":           toPascalCase,
-		"mapType":                mapType,
-		"intSliceToBytesLiteral": intSliceToBytesLiteral,
-		"manualDiscriminator":    manualDiscriminator,
+// encodedTypes returns the types of the fields Borsh encodes, in order.
+func encodedTypes(fields []IdlField) []IdlType {
+	var types []IdlType
+	for _, f := range fields {
+		if !fieldSkipped(f.Attrs) {
+			types = append(types, f.Type)
+		}
 	}
+	return types
+}
 
-	tmpl, err := template.New("idl").Funcs(funcMap).Parse(goTemplate)
-	if err != nil {
-		return err
+// derefName returns *name, or "" when name is nil.
+func derefName(name *string) string {
+	if name == nil {
+		return ""
 	}
+	return *name
+}
 
-	var buf bytes.Buffer
-	dataMap := struct {
-		PackageName string
-		ClientName  string
-		Prefix      string
-		IDL         IDL
-	}{
-		PackageName: *pkgName,
-		ClientName:  *clientName,
-		Prefix:      prefix,
-		IDL:         idl,
-	}
+// hasMap reports whether t is or contains a hashMap or btreeMap.
+func hasMap(t IdlType) bool {
+	found := false
+	walkType(t, func(t IdlType) {
+		if t.Map != nil {
+			found = true
+		}
+	})
+	return found
+}
 
-	if err := tmpl.Execute(&buf, dataMap); err != nil {
-		return err
+// optionTag returns the bin tag option that makes the Borsh codec write the
+// presence prefix of an option (1 byte) or coption (4 bytes) field.
+func optionTag(t IdlType) string {
+	switch {
+	case t.Option != nil:
+		return " optional"
+	case t.Coption != nil:
+		return " coption"
 	}
+	return ""
+}
 
-	formatted, err := format.Source(buf.Bytes())
-	if err != nil {
-		if verbose {
-			log.Printf("Warning: Code format failed: %v. Writing unformatted code.", err)
+// nestedOption reports whether t holds an option that no struct tag reaches,
+// such as the inner option of option<option<u64>> or the elements of
+// vec<option<u8>>. Fields and tuple elements are tagged by optionTag.
+func nestedOption(t IdlType) bool {
+	var unreachable func(t IdlType, tagged bool) bool
+	unreachable = func(t IdlType, tagged bool) bool {
+		switch {
+		case t.Option != nil:
+			return !tagged || unreachable(innerType(*t.Option), false)
+		case t.Coption != nil:
+			return !tagged || unreachable(innerType(*t.Coption), false)
+		case t.Vec != nil:
+			return unreachable(innerType(*t.Vec), false)
+		case t.Array != nil:
+			return unreachable(innerType((*t.Array)[0]), false)
+		case t.Map != nil:
+			return unreachable(innerType((*t.Map)[0]), false) || unreachable(innerType((*t.Map)[1]), false)
+		case t.Tuple != nil:
+			for _, elem := range t.Tuple {
+				if unreachable(innerType(elem), true) {
+					return true
+				}
+			}
 		}
-		return os.WriteFile(*outPath, buf.Bytes(), 0644)
+		return false
 	}
-
-	return os.WriteFile(*outPath, formatted, 0644)
+	return unreachable(t, true)
 }
 
-// --- Template ---
-
-const goTemplate = `// Code generated by idlgen. DO NOT EDIT.
-// Program: {{ .IDL.Name }}
+// registryEntry is one entry of the discriminator registry map literal.
+type registryEntry struct {
+	Var  string // discriminator variable
+	Name string // "instruction:<name>" or "account:<name>"
+}
 
-package {{ .PackageName }}
+// registryEntries lists the registry entries of the 8-byte instruction and
+// account discriminators, sorted by name so the map literal does not depend
+// on the IDL order.
+func registryEntries(idl IDL, prefix string) []registryEntry {
+	var entries []registryEntry
+	for _, instr := range idl.Instructions {
+		if discriminatorLen(instr.Discriminator) == 8 {
+			entries = append(entries, registryEntry{Var: prefix + toPascalCase(instr.Name) + "Discriminator", Name: "instruction:" + instr.Name})
+		}
+	}
+	for _, a := range idl.Accounts {
+		if discriminatorLen(a.Discriminator) == 8 {
+			entries = append(entries, registryEntry{Var: prefix + toPascalCase(a.Name) + "Discriminator", Name: "account:" + a.Name})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
 
-import (
-	"bytes"
-	"errors"
-	"fmt"
+// sortedErrors returns the errors ordered by code, for the code lookup map.
+func sortedErrors(errs []IdlError) []IdlError {
+	sorted := append([]IdlError(nil), errs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Code < sorted[j].Code })
+	return sorted
+}
 
-	bin "github.com/gagliardetto/binary"
-	"github.com/gagliardetto/solana-go"
-	"github.com/gagliardetto/solana-go/rpc"
-)
+// constDecl is a rendered Go declaration for an IDL constant.
+type constDecl struct {
+	Keyword string // "const" or "var"
+	Type    string // Go type, empty to let the value determine it
+	Value   string // Go literal or expression
+}
 
-// ProgramID is the public key of the program.
-var {{ .Prefix }}ProgramID = solana.MustPublicKeyFromBase58("{{ .IDL.Address }}")
+// newConstDecl converts an IDL constant value into a Go declaration. goType is
+// the mapped Go type of the constant.
+func newConstDecl(c IdlConst, goType string) (constDecl, error) {
+	value := strings.TrimSpace(c.Value)
+	switch {
+	case c.Type.Primitive == "string":
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		return constDecl{Keyword: "const", Type: "string", Value: strconv.Quote(value)}, nil
+	case isPubkey(c.Type):
+		return constDecl{Keyword: "var", Value: fmt.Sprintf("solana.MustPublicKeyFromBase58(%q)", value)}, nil
+	case c.Type.Primitive == "bool":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return constDecl{}, fmt.Errorf("constant %s: invalid bool value %q", c.Name, c.Value)
+		}
+		return constDecl{Keyword: "const", Type: "bool", Value: value}, nil
+	case c.Type.Primitive == "bytes" || c.Type.Array != nil || c.Type.Vec != nil:
+		var nums []int
+		if err := json.Unmarshal([]byte(value), &nums); err != nil {
+			return constDecl{}, fmt.Errorf("constant %s: invalid byte array value %q", c.Name, c.Value)
+		}
+		return constDecl{Keyword: "var", Value: "[]byte{" + intSliceToBytesLiteral(nums) + "}"}, nil
+	}
 
-// --- Errors ---
-{{- range .IDL.Errors }}
-// Err{{ $.Prefix }}{{ .Name | toPascalCase }} represents the error {{ .Name }}.
-var Err{{ $.Prefix }}{{ .Name | toPascalCase }} = errors.New("{{ .Message }}")
-{{- end }}
+	number := strings.ReplaceAll(value, "_", "")
+	_, intErr := strconv.ParseInt(number, 0, 64)
+	_, uintErr := strconv.ParseUint(number, 0, 64)
+	_, floatErr := strconv.ParseFloat(number, 64)
+	if intErr != nil && uintErr != nil && floatErr != nil {
+		return constDecl{}, fmt.Errorf("constant %s: unsupported value %q", c.Name, c.Value)
+	}
+	if goType == "interface{}" || strings.HasPrefix(goType, "bin.") {
+		// usize and friends have no Go mapping; leave the constant untyped.
+		goType = ""
+	}
+	return constDecl{Keyword: "const", Type: goType, Value: number}, nil
+}
 
-// --- Types ---
-{{- range .IDL.Types }}
-{{ $typeName := .Name | toPascalCase }}
-{{- if eq .Type.Kind "struct" }}
-// {{ $.Prefix }}{{ $typeName }} represents the struct {{ .Name }}.
-type {{ $.Prefix }}{{ $typeName }} struct {
-	{{- range .Type.Fields }}
-	{{ .Name | toPascalCase }} {{ mapType .Type }} ` + "`" + `bin:"{{ .Name }}"` + "`" + `
-	{{- end }}
+// walkType calls fn for t and every type nested inside it.
+func walkType(t IdlType, fn func(IdlType)) {
+	fn(t)
+	switch {
+	case t.Option != nil:
+		walkType(innerType(*t.Option), fn)
+	case t.Coption != nil:
+		walkType(innerType(*t.Coption), fn)
+	case t.Vec != nil:
+		walkType(innerType(*t.Vec), fn)
+	case t.Map != nil:
+		walkType(innerType((*t.Map)[0]), fn)
+		walkType(innerType((*t.Map)[1]), fn)
+	case t.Array != nil:
+		walkType(innerType((*t.Array)[0]), fn)
+	case t.Tuple != nil:
+		for _, elem := range t.Tuple {
+			walkType(innerType(elem), fn)
+		}
+	case t.Generics != nil:
+		for _, arg := range t.Generics {
+			walkType(innerType(arg), fn)
+		}
+	}
 }
-{{- else if eq .Type.Kind "enum" }}
-// Enum: {{ $.Prefix }}{{ $typeName }}
-type {{ $.Prefix }}{{ $typeName }} = bin.BorshEnum
-{{- end }}
-{{- end }}
+
+// walkTypes calls fn for every type used by the IDL, including the element
+// types nested inside vecs, options, maps and arrays.
+func walkTypes(idl IDL, fn func(IdlType)) {
+	visit := func(t IdlType) {
+		walkType(t, fn)
+	}
+	visitFields := func(fields []IdlField) {
+		for _, f := range fields {
+			visit(f.Type)
+		}
+	}
+	for _, t := range idl.Types {
+		visitFields(t.Type.Fields)
+		for _, v := range t.Type.Variants {
+			for _, f := range v.Fields {
+				visit(f.Type)
+			}
+		}
+	}
+	for _, a := range idl.Accounts {
+		if a.Type != nil {
+			visitFields(a.Type.Fields)
+		}
+	}
+	for _, e := range idl.Events {
+		visitFields(e.Fields)
+	}
+	for _, instr := range idl.Instructions {
+		visitFields(instr.Args)
+		if instr.Returns != nil {
+			visit(*instr.Returns)
+		}
+	}
+	for _, c := range idl.Constants {
+		visit(c.Type)
+	}
+}
+
+// goImport is an additional import needed by the generated code.
+type goImport struct {
+	Alias string // empty when the package name matches the last path element
+	Path  string
+}
+
+// parseQualifiedType splits a qualified type such as "math/big.Int" into the
+// Go type expression ("big.Int") and its import. Unqualified names are local
+// and need no import.
+func parseQualifiedType(spec string) (string, *goImport) {
+	dot := strings.LastIndex(spec, ".")
+	if dot < 0 {
+		return spec, nil
+	}
+	path, name := spec[:dot], spec[dot+1:]
+	elems := strings.Split(path, "/")
+	alias := elems[len(elems)-1]
+	if len(elems) > 1 && len(alias) > 1 && alias[0] == 'v' && strings.Trim(alias[1:], "0123456789") == "" {
+		// Major version suffix, e.g. github.com/org/pkg/v2.
+		alias = elems[len(elems)-2]
+	}
+	alias = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, alias)
+	imp := &goImport{Path: path}
+	if alias != elems[len(elems)-1] {
+		imp.Alias = alias
+	}
+	return alias + "." + name, imp
+}
+
+// --- Generator ---
+
+// binaryMethods is the input of the "binaryMethods" template.
+type binaryMethods struct {
+	TypeName string // full Go type name
+	Account  bool   // prefix the encoding with the account discriminator
+}
+
+// hasMethods is the input of the "hasMethods" template.
+type hasMethods struct {
+	TypeName string   // full Go type name
+	Fields   []string // Go names of the option fields
+}
+
+// validateMethod is the input of the "validateMethod" template.
+type validateMethod struct {
+	TypeName string   // full Go type name
+	Checks   []string // Go statements returning an error when a constraint fails
+}
+
+// describeMethod is the input of the "describeMethod" template.
+type describeMethod struct {
+	TypeName string   // full Go type name
+	Fields   []string // Go statements writing one field to the builder b
+}
+
+// equalMethod is the input of the "equalMethod" template.
+type equalMethod struct {
+	TypeName string   // full Go type name
+	Checks   []string // Go statements returning false when a field differs
+}
+
+// maxLenDoc matches a documented length limit such as "max len 32" or
+// "Maximum length: MAX_NAME_LEN" in field docs.
+var maxLenDoc = regexp.MustCompile(`(?i)\bmax(?:imum)?[ _]len(?:gth)?\b[\s:=(]*([0-9]+|[A-Za-z_][A-Za-z0-9_]*)`)
+
+// computeUnitsDoc matches a documented compute estimate such as
+// "Compute units: 45,000" or "uses ~12000 CU" in instruction docs.
+var computeUnitsDoc = regexp.MustCompile(`(?i)\bcompute[ _-]?units?\b[\s:=~(]*([0-9][0-9,_]*)|\b([0-9][0-9,_]*)\s*(?:compute[ _-]?units?|CUs?)\b`)
+
+// computeUnits returns the compute unit estimate found in docs, or "" when
+// they document none.
+func computeUnits(docs []string) string {
+	for _, doc := range docs {
+		if match := computeUnitsDoc.FindStringSubmatch(doc); match != nil {
+			n := strings.NewReplacer(",", "", "_", "").Replace(match[1] + match[2])
+			if _, err := strconv.ParseUint(n, 10, 32); err == nil {
+				return n
+			}
+		}
+	}
+	return ""
+}
+
+// pdaHelper holds the pieces needed to render a PDA derivation function.
+type pdaHelper struct {
+	Params  []string // "name type" function parameters
+	Encoded []string // parameters that must be Borsh-encoded into <name>Seed first
+	Seeds   []string // seed expressions in order
+	Program string   // program ID expression, empty for the program itself
+}
+
+// argPathType resolves the IDL type of an instruction arg path such as "amount" or "params.owner".
+func argPathType(instr IdlInstruction, path string, types []IdlTypeDefinition) IdlType {
+	parts := strings.Split(path, ".")
+	fields := instr.Args
+	var typ IdlType
+	for i, part := range parts {
+		found := false
+		for _, f := range fields {
+			if f.Name == part {
+				typ, found = f.Type, true
+				break
+			}
+		}
+		if !found || i == len(parts)-1 || typ.Defined == nil {
+			break
+		}
+		fields = nil
+		for _, t := range types {
+			if t.Name == *typ.Defined {
+				fields = t.Type.Fields
+				break
+			}
+		}
+	}
+	return typ
+}
+
+// Default import paths of the packages the generated code depends on.
+const (
+	defaultBinImport    = "github.com/gagliardetto/binary"
+	defaultSolanaImport = "github.com/gagliardetto/solana-go"
+	defaultRPCImport    = "github.com/gagliardetto/solana-go/rpc"
+)
+
+// importSpec renders an import line for pkg, honoring an override path. The
+// package is always referred to as name, so overrides get an explicit alias.
+func importSpec(name, override, def string) string {
+	if override == "" || override == def {
+		if name == "bin" {
+			return fmt.Sprintf("bin %q", def)
+		}
+		return strconv.Quote(def)
+	}
+	return fmt.Sprintf("%s %q", name, override)
+}
+
+// NoClient as Options.ClientName disables client generation, dropping the rpc dependency.
+const NoClient = "none"
+
+// Options configures code generation.
+type Options struct {
+	PackageName        string            // Go package name of the generated file, defaults to "main"
+	GoVersion          string            // Go version the output targets, e.g. "1.17"; below 1.18 generics are monomorphized; defaults to the running toolchain's
+	Header             string            // banner emitted above the "Code generated" line, e.g. a license
+	ClientName         string            // client struct name, defaults to <Prefix>Client
+	ProgramName        string            // program name used when the IDL does not name the program
+	Prefix             string            // prefix of generated identifiers, defaults to the PascalCase program name
+	TypeMap            map[string]string // IDL defined type name -> external Go type ("import/path.GoType")
+	PrimitiveMap       map[string]string // IDL primitive -> Go type ("import/path.GoType"), also overriding built-in primitives
+	JSONTags           bool              // emit json tags next to bin tags
+	Equal              bool              // generate Equal methods on structs and complex enums
+	Validate           bool              // generate Validate methods checking enum ranges and documented max lengths
+	Describe           bool              // generate Describe methods rendering accounts with base58 pubkeys and hex bytes
+	TagCase            string            // casing of bin tag names: TagCaseRaw (default), TagCaseSnake or TagCaseCamel
+	EnumJSON           bool              // encode enums to JSON by variant name
+	AllowUnformatted   bool              // write unformatted code instead of failing when go/format rejects it
+	AST                bool              // experimental: build the struct declarations of the Types section with go/ast
+	Verify             bool              // compile the generated code before writing it (needs a Go toolchain)
+	UndefinedAsBytes   bool              // map defined references without a type definition to []byte instead of failing
+	InlineByteArrays   bool              // replace references to single-field u8 array structs (e.g. Hash) with [N]byte
+	Strict             bool              // fail on unknown primitive types and ValidateIDL problems
+	DiscriminatorMode  string            // DiscriminatorModeAnchor (default) or DiscriminatorModeU32LE for instruction tags
+	NoDiscFallback     bool              // fail when an instruction or account has no IDL discriminator instead of deriving one
+	AccountNameCase    string            // name casing hashed into derived account discriminators: AccountCasePascal (default, Anchor's), AccountCaseSnake or AccountCaseRaw
+	InstrNameCase      string            // name casing hashed into derived instruction discriminators: InstructionCaseSnake (default, Anchor's), InstructionCaseCamel or InstructionCaseRaw
+	InstrNamespace     string            // namespace hashed before instruction names, defaults to DefaultInstructionNamespace
+	DiscriminatorArray bool              // emit discriminators as fixed-size byte arrays instead of []byte slices
+	Constructors       bool              // generate New<Prefix><Type> constructors for struct types
+	OptionHelpers      bool              // generate Has<Field> methods for the option fields of structs
+	Assertions         bool              // emit compile-time interface assertions for generated methods
+	ComputeHints       bool              // emit <Prefix><Instr>ComputeUnits for compute estimates found in instruction docs
+	Builders           bool              // generate a fluent builder per instruction
+	SPLHelpers         bool              // generate an associated token address helper for programs taking token accounts
+	CheckAccounts      bool              // New<Instr>Instruction rejects zero required accounts and returns an error
+	BinImport          string            // import path for the bin package, for forks or vendored copies
+	SolanaImport       string            // import path for the solana package
+	RPCImport          string            // import path for the rpc package
+	U128Type           string            // Go type for u128, defaults to bin.Uint128 ("import/path.GoType" adds an import)
+	I128Type           string            // Go type for i128, defaults to bin.Int128
+	U256Type           string            // Go type for u256, defaults to [32]byte holding the little-endian bytes
+	I256Type           string            // Go type for i256, defaults to [32]byte holding the little-endian two's complement bytes
+	Minimal            bool              // emit only types, Args/Accounts structs, discriminators and Borsh codecs, without rpc or instruction constructors
+	PubkeyType         string            // Go type for pubkeys in minimal mode, defaults to solana.PublicKey ("import/path.GoType" adds an import)
+	PackagePerProgram  bool              // GenerateDir writes <out>/<pkg>/<pkg>.go and a doc.go per program, named after the program
+	ProtoPath          string            // also write proto3 messages for the IDL here, or <idl-name>.proto files into this directory in GenerateDir
+	TSPath             string            // also write TypeScript definitions here, or <idl-name>.ts files into this directory in GenerateDir
+	Incremental        bool              // skip writing when the output records the same input sum
+	Force              bool              // regenerate in incremental mode even when the input is unchanged
+	Jobs               int               // concurrent generations in GenerateDir, defaults to runtime.NumCPU()
+	Verbose            bool
+}
+
+// Generate processes the IDL and outputs the Go binding file.
+// typeMap maps IDL defined type names to external Go types ("Name" -> "import/path.GoType").
+//
+// Deprecated: Use GenerateWithOptions.
+func Generate(idlPath, outPath, pkgName, clientName *string, typeMap map[string]string, verbose bool) error {
+	return GenerateWithOptions(*idlPath, *outPath, Options{
+		PackageName: *pkgName,
+		ClientName:  *clientName,
+		TypeMap:     typeMap,
+		Verbose:     verbose,
+	})
+}
+
+// GenerateWithOptions reads the IDL at idlPath and writes the Go bindings to
+// outPath. Either path may be "-" for stdin/stdout. When opts.ProgramName is
+// empty it defaults to the IDL file name.
+func GenerateWithOptions(idlPath, outPath string, opts Options) error {
+	if idlPath == "" || outPath == "" {
+		return fmt.Errorf("idl and out paths are required")
+	}
+
+	data, err := readInput(idlPath)
+	if err != nil {
+		return err
+	}
+
+	if opts.ProgramName == "" && idlPath != stdioPath {
+		opts.ProgramName = programNameFromPath(idlPath)
+	}
+
+	return generateToPath(data, outPath, opts)
+}
+
+// programNameFromPath names a program after its (first) IDL file or URL, without the extension.
+func programNameFromPath(idlPath string) string {
+	idlPath, _, _ = strings.Cut(idlPath, ",")
+	fileName := filepath.Base(idlPath)
+	if u, err := url.Parse(idlPath); err == nil && isURL(idlPath) {
+		fileName = path.Base(u.Path)
+	}
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+}
+
+// generateToPath generates bindings from IDL data and writes them to outPath,
+// keeping the unformatted source in a .debug file when formatting fails.
+func generateToPath(data []byte, outPath string, opts Options) error {
+	if opts.Incremental && !opts.Force && outPath != stdioPath {
+		if existing, err := os.ReadFile(outPath); err == nil && bytes.Contains(existing, []byte(sumLine(inputSum(data, opts)))) {
+			if opts.Verbose {
+				log.Println("Up to date:", outPath)
+			}
+			return writeSchemas(data, opts)
+		}
+	}
+
+	code, err := generate(data, opts)
+	if err != nil {
+		var fe *FormatError
+		if errors.As(err, &fe) && outPath != stdioPath {
+			debugPath := outPath + ".debug"
+			if os.WriteFile(debugPath, fe.Source, 0644) == nil {
+				fe.DebugPath = debugPath
+			}
+		}
+		return err
+	}
+	if err := writeOutput(outPath, code); err != nil {
+		return err
+	}
+	return writeSchemas(data, opts)
+}
+
+// writeSchemas writes the proto3 and TypeScript definitions of the IDL data
+// to opts.ProtoPath and opts.TSPath, if set, reusing the input the bindings
+// were generated from.
+func writeSchemas(data []byte, opts Options) error {
+	if opts.ProtoPath == "" && opts.TSPath == "" {
+		return nil
+	}
+	idl, err := parseIDL(data)
+	if err != nil {
+		return err
+	}
+	if opts.ProtoPath != "" {
+		if err := writeOutput(opts.ProtoPath, generateProto(idl, opts)); err != nil {
+			return err
+		}
+	}
+	if opts.TSPath != "" {
+		return writeOutput(opts.TSPath, generateTypeScript(idl, opts))
+	}
+	return nil
+}
+
+// Version is the idlgen version recorded in generated headers. When empty, the
+// module version from the build info is used.
+var Version = ""
+
+const modulePath = "github.com/fakhrilainur/idlgen"
+
+// version returns Version or the idlgen module version this binary was built with.
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath && info.Main.Version != "" {
+			return info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				return dep.Version
+			}
+		}
+	}
+	return "(devel)"
+}
+
+// inputSum hashes the IDL together with the options that shape the output,
+// so a change to either triggers regeneration in incremental mode.
+func inputSum(data []byte, opts Options) string {
+	opts.Verbose, opts.Incremental, opts.Force, opts.Jobs = false, false, false, 0
+	h := sha256.New()
+	h.Write(data)
+	fmt.Fprintf(h, "%#v", opts)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// minGoMinor is the oldest Go 1.x minor release the generated code supports;
+// it converts slices to array pointers, added in Go 1.17. Generic IDL types
+// are monomorphized for targets before Go 1.18, which added type parameters.
+const minGoMinor = 17
+
+// goMinorVersion returns the minor release of a Go version such as "1.21",
+// "go1.21.3" or "go1.22rc1". Development builds count as the newest release.
+func goMinorVersion(v string) (int, error) {
+	if strings.HasPrefix(v, "devel") {
+		return math.MaxInt32, nil
+	}
+	rest, ok := strings.CutPrefix(strings.TrimPrefix(v, "go"), "1.")
+	if !ok {
+		return 0, fmt.Errorf("invalid go version %q, want 1.N", v)
+	}
+	end := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsDigit(r) })
+	if end < 0 {
+		end = len(rest)
+	}
+	minor, err := strconv.Atoi(rest[:end])
+	if err != nil {
+		return 0, fmt.Errorf("invalid go version %q, want 1.N", v)
+	}
+	return minor, nil
+}
+
+// banner formats header as a comment block followed by a blank line, so it
+// stays apart from the "Code generated" line and the package doc. Lines that
+// are already comments are kept as they are.
+func banner(header string) string {
+	header = strings.TrimRight(header, "\n")
+	if strings.TrimSpace(header) == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(header, "\n") {
+		line = strings.TrimRight(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "//"):
+			b.WriteString(line)
+		case line == "":
+			b.WriteString("//")
+		default:
+			b.WriteString("// " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// sumLine is the header comment recording the input sum of a generated file.
+func sumLine(sum string) string {
+	return "// Input sha256: " + sum + "\n"
+}
+
+// GenerateFromReader reads an IDL from r and writes the Go bindings to w.
+func GenerateFromReader(r io.Reader, w io.Writer, opts Options) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	code, err := generate(data, opts)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(code)
+	return err
+}
+
+// parseIDL decodes raw IDL JSON, monomorphizing generic types, flattening
+// account groups and naming tuple fields.
+func parseIDL(data []byte) (IDL, error) {
+	return decodeIDL(data, false)
+}
+
+// decodeIDL is parseIDL, except that with typeParams set generic types are
+// kept for emission with Go type parameters when keepsGenerics allows it.
+func decodeIDL(data []byte, typeParams bool) (IDL, error) {
+	var idl IDL
+	// Monomorphizing also reports malformed instantiations, so it runs even
+	// when its output is unused.
+	mono, err := monomorphize(data)
+	if err != nil {
+		return idl, fmt.Errorf("failed to parse IDL: %v", err)
+	}
+	if !typeParams || !keepsGenerics(data) {
+		data = mono
+	}
+	if err := json.Unmarshal(data, &idl); err != nil {
+		return idl, fmt.Errorf("failed to parse IDL: %v", err)
+	}
+	for i := range idl.Instructions {
+		idl.Instructions[i].Accounts = flattenAccounts(idl.Instructions[i].Accounts, "")
+	}
+	nameTupleFields(&idl)
+	return idl, nil
+}
+
+// generate renders the Go bindings for the raw IDL JSON in data.
+func generate(data []byte, opts Options) ([]byte, error) {
+	idlSum := fmt.Sprintf("%x", sha256.Sum256(data))
+	var sum string
+	if opts.Incremental {
+		sum = inputSum(data, opts)
+	}
+	goVersion := opts.GoVersion
+	if goVersion == "" {
+		goVersion = runtime.Version()
+	}
+	goMinor, err := goMinorVersion(goVersion)
+	if err != nil {
+		return nil, err
+	}
+	if goMinor < minGoMinor {
+		return nil, fmt.Errorf("go version %s is not supported, generated code needs go1.%d or later", goVersion, minGoMinor)
+	}
+	if opts.InlineByteArrays {
+		inlined, err := inlineByteArrays(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse IDL: %v", err)
+		}
+		data = inlined
+	}
+	idl, err := decodeIDL(data, goMinor >= 18)
+	if err != nil {
+		return nil, err
+	}
+
+	if idl.Name == "" {
+		idl.Name = idl.Metadata.Name
+	}
+	if (idl.Name == "" || idl.Name == "program") && opts.ProgramName != "" {
+		idl.Name = opts.ProgramName
+	}
+
+	switch opts.DiscriminatorMode {
+	case "", DiscriminatorModeAnchor:
+	case DiscriminatorModeU32LE:
+		// Instructions are tagged with a little-endian u32: a single-element
+		// IDL discriminator is the tag, otherwise the instruction index is.
+		for i := range idl.Instructions {
+			d := idl.Instructions[i].Discriminator
+			switch len(d) {
+			case 0:
+				idl.Instructions[i].Discriminator = u32leDiscriminator(uint32(i))
+			case 1:
+				idl.Instructions[i].Discriminator = u32leDiscriminator(uint32(d[0]))
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown discriminator mode %q (want %s or %s)", opts.DiscriminatorMode, DiscriminatorModeAnchor, DiscriminatorModeU32LE)
+	}
+
+	if opts.NoDiscFallback {
+		if missing := missingDiscriminators(idl); len(missing) > 0 {
+			return nil, fmt.Errorf("IDL has no discriminator for:\n  %s", strings.Join(missing, "\n  "))
+		}
+	}
+
+	if problems := validateIDL(idl, func(name string) bool { return opts.TypeMap[name] != "" || opts.UndefinedAsBytes }); len(problems) > 0 {
+		if opts.Strict {
+			lines := make([]string, len(problems))
+			for i, p := range problems {
+				lines[i] = p.Error()
+			}
+			return nil, fmt.Errorf("invalid IDL:\n  %s", strings.Join(lines, "\n  "))
+		}
+		if opts.Verbose {
+			for _, p := range problems {
+				log.Printf("Warning: %v", p)
+			}
+		}
+	}
+
+	if idl.Address == "" {
+		idl.Address = idl.Metadata.Address
+	}
+	if idl.Metadata.Version == "" {
+		idl.Metadata.Version = idl.Version
+	}
+	if idl.Address == "" {
+		return nil, fmt.Errorf("no program address found in IDL (expected \"address\" or \"metadata.address\")")
+	}
+
+	// toArray converts the byte slice expr to a [n]byte value, through an
+	// array pointer before Go 1.20 allowed converting to arrays directly.
+	toArray := func(n int, expr string) string {
+		if goMinor >= 20 {
+			return fmt.Sprintf("[%d]byte(%s)", n, expr)
+		}
+		return fmt.Sprintf("*(*[%d]byte)(%s)", n, expr)
+	}
+
+	switch opts.AccountNameCase {
+	case "", AccountCasePascal, AccountCaseSnake, AccountCaseRaw:
+	default:
+		return nil, fmt.Errorf("unknown account discriminator case %q (want %s, %s or %s)", opts.AccountNameCase, AccountCasePascal, AccountCaseSnake, AccountCaseRaw)
+	}
+
+	switch opts.InstrNameCase {
+	case "", InstructionCaseSnake, InstructionCaseCamel, InstructionCaseRaw:
+	default:
+		return nil, fmt.Errorf("unknown instruction discriminator case %q (want %s, %s or %s)", opts.InstrNameCase, InstructionCaseSnake, InstructionCaseCamel, InstructionCaseRaw)
+	}
+	instrNamespace := opts.InstrNamespace
+	if instrNamespace == "" {
+		instrNamespace = DefaultInstructionNamespace
+	}
+
+	switch opts.TagCase {
+	case "", TagCaseRaw, TagCaseSnake, TagCaseCamel:
+	default:
+		return nil, fmt.Errorf("unknown tag case %q (want %s, %s or %s)", opts.TagCase, TagCaseRaw, TagCaseSnake, TagCaseCamel)
+	}
+
+	prefix := toPascalCase(idl.Name)
+	if opts.Prefix != "" {
+		if !token.IsIdentifier(opts.Prefix) {
+			return nil, fmt.Errorf("prefix %q is not a valid Go identifier", opts.Prefix)
+		}
+		prefix = opts.Prefix
+	}
+
+	if err := checkIdentifiers(idl, prefix); err != nil {
+		return nil, err
+	}
+
+	undefined, undefinedNames := undefinedTypes(idl, opts.TypeMap)
+	if len(undefined) > 0 {
+		if !opts.UndefinedAsBytes {
+			return nil, fmt.Errorf("IDL references types it does not define:\n  %s", strings.Join(undefined, "\n  "))
+		}
+		if opts.Verbose {
+			for _, u := range undefined {
+				log.Printf("Warning: %s, mapped to []byte", u)
+			}
+		}
+	}
+
+	if unknown := unknownPrimitives(idl, opts.PrimitiveMap); len(unknown) > 0 {
+		if opts.Strict {
+			return nil, fmt.Errorf("IDL uses unknown primitive types:\n  %s", strings.Join(unknown, "\n  "))
+		}
+		if opts.Verbose {
+			for _, u := range unknown {
+				log.Printf("Warning: %s, mapped to interface{}", u)
+			}
+		}
+	}
+
+	clientName := opts.ClientName
+	switch {
+	case opts.Minimal || clientName == NoClient:
+		clientName = ""
+	case clientName == "":
+		clientName = prefix + "Client"
+	}
+	if opts.PackageName == "" {
+		opts.PackageName = "main"
+	}
+
+	// Primitive overrides and defined types mapped to external packages; only
+	// the ones actually referenced are imported.
+	primitiveOverrides := make(map[string]string)
+	for primitive, spec := range opts.PrimitiveMap {
+		primitiveOverrides[primitive] = spec
+	}
+	// Mapping either pubkey spelling covers the other.
+	if spec, ok := opts.PrimitiveMap["pubkey"]; ok && opts.PrimitiveMap["publicKey"] == "" {
+		primitiveOverrides["publicKey"] = spec
+	}
+	if spec, ok := opts.PrimitiveMap["publicKey"]; ok && opts.PrimitiveMap["pubkey"] == "" {
+		primitiveOverrides["pubkey"] = spec
+	}
+	for primitive, spec := range map[string]string{"u128": opts.U128Type, "i128": opts.I128Type, "u256": opts.U256Type, "i256": opts.I256Type} {
+		if spec != "" {
+			primitiveOverrides[primitive] = spec
+		}
+	}
+	if opts.Minimal {
+		if opts.PubkeyType != "" {
+			primitiveOverrides["pubkey"] = opts.PubkeyType
+			primitiveOverrides["publicKey"] = opts.PubkeyType
+		}
+		// Minimal output keeps pubkey constants as base58 strings.
+		for i, c := range idl.Constants {
+			if isPubkey(c.Type) {
+				idl.Constants[i].Type = IdlType{Primitive: "string"}
+			}
+		}
+	}
+	externalTypes := make(map[string]string, len(opts.TypeMap))
+	primitiveTypes := make(map[string]string, len(primitiveOverrides))
+	var imports []goImport
+	importSeen := make(map[string]bool)
+	useQualified := func(spec string) string {
+		goType, imp := parseQualifiedType(spec)
+		if imp != nil && !importSeen[imp.Path] {
+			importSeen[imp.Path] = true
+			imports = append(imports, *imp)
+		}
+		return goType
+	}
+	usesPubkey := false
+	walkTypes(idl, func(t IdlType) {
+		if isPubkey(t) {
+			usesPubkey = true
+		}
+		if spec, ok := primitiveOverrides[t.Primitive]; ok {
+			primitiveTypes[t.Primitive] = useQualified(spec)
+		}
+		if t.Defined == nil {
+			return
+		}
+		if spec, ok := opts.TypeMap[*t.Defined]; ok {
+			externalTypes[*t.Defined] = useQualified(spec)
+		}
+	})
+	publicKeyType := "solana.PublicKey"
+	for _, instr := range idl.Instructions {
+		if len(instr.Accounts) > 0 {
+			usesPubkey = true
+		}
+	}
+	// Instruction accounts stay solana.PublicKey outside minimal mode, where
+	// they are turned into account metas.
+	if spec, ok := primitiveOverrides["pubkey"]; ok && opts.Minimal && usesPubkey {
+		publicKeyType = useQualified(spec)
+	}
+
+	// Numeric constants can stand in for symbolic array sizes.
+	constSizes := make(map[string]string)
+	constValues := make(map[string]int)
+	for _, c := range idl.Constants {
+		if n, err := strconv.ParseUint(strings.ReplaceAll(c.Value, "_", ""), 0, 64); err == nil {
+			constSizes[c.Name] = prefix + toPascalCase(c.Name)
+			constValues[c.Name] = int(n)
+		}
+	}
+
+	var mapType func(t IdlType) string
+	mapType = func(t IdlType) string {
+		if t.Primitive != "" {
+			if goType, ok := primitiveTypes[t.Primitive]; ok {
+				return goType
+			}
+			switch t.Primitive {
+			case "bool":
+				return "bool"
+			case "u8":
+				return "uint8"
+			case "i8":
+				return "int8"
+			case "u16":
+				return "uint16"
+			case "i16":
+				return "int16"
+			case "u32":
+				return "uint32"
+			case "i32":
+				return "int32"
+			case "u64":
+				return "uint64"
+			case "i64":
+				return "int64"
+			case "u128":
+				return "bin.Uint128"
+			case "i128":
+				return "bin.Int128"
+			case "u256", "i256":
+				return "[32]byte"
+			case "f32":
+				return "float32"
+			case "f64":
+				return "float64"
+			case "bytes":
+				return "[]byte"
+			case "string":
+				return "string"
+			case "pubkey", "publicKey":
+				return "solana.PublicKey"
+			default:
+				return "interface{}"
+			}
+		}
+		if t.Generic != "" {
+			return t.Generic
+		}
+		if t.Defined != nil {
+			if goType, ok := externalTypes[*t.Defined]; ok {
+				return goType
+			}
+			if undefinedNames[*t.Defined] {
+				return "[]byte"
+			}
+			name := prefix + toPascalCase(*t.Defined)
+			if len(t.Generics) > 0 {
+				args := make([]string, len(t.Generics))
+				for i, arg := range t.Generics {
+					args[i] = mapType(innerType(arg))
+				}
+				name += "[" + strings.Join(args, ", ") + "]"
+			}
+			return name
+		}
+		if t.Option != nil {
+			inner := innerType(*t.Option)
+			return "*" + mapType(inner)
+		}
+		if t.Coption != nil {
+			inner := innerType(*t.Coption)
+			return "*" + mapType(inner)
+		}
+		if t.Vec != nil {
+			inner := innerType(*t.Vec)
+			return "[]" + mapType(inner)
+		}
+		if t.Map != nil {
+			return "map[" + mapType(innerType((*t.Map)[0])) + "]" + mapType(innerType((*t.Map)[1]))
+		}
+		if t.Tuple != nil {
+			fields := make([]string, len(t.Tuple))
+			for i, elem := range t.Tuple {
+				fields[i] = fmt.Sprintf("Field%d %s", i, mapType(innerType(elem)))
+				if tag := optionTag(innerType(elem)); tag != "" {
+					fields[i] += fmt.Sprintf(" `bin:%q`", strings.TrimSpace(tag))
+				}
+			}
+			return "struct{ " + strings.Join(fields, "; ") + " }"
+		}
+		if t.Array != nil {
+			inner := innerType((*t.Array)[0])
+			if size, ok := arraySize((*t.Array)[1]); ok {
+				return fmt.Sprintf("[%d]%s", size, mapType(inner))
+			}
+			// Symbolic sizes ("MAX_LEN" or {"defined": "MAX_LEN"}) resolve through
+			// the IDL constants, falling back to a slice when unknown.
+			if ref := constSizes[symbolicArraySize((*t.Array)[1])]; ref != "" {
+				return fmt.Sprintf("[%s]%s", ref, mapType(inner))
+			}
+			return "[]" + mapType(inner)
+		}
+		return "interface{}"
+	}
+
+	typeNames := make(map[string]bool, len(idl.Types))
+	for _, t := range idl.Types {
+		typeNames[t.Name] = true
+	}
+	hasType := func(name string) bool {
+		return typeNames[name]
+	}
+	accountNames := make(map[string]bool, len(idl.Accounts))
+	for _, a := range idl.Accounts {
+		accountNames[a.Name] = true
+	}
+	isAccount := func(name string) bool {
+		return accountNames[name]
+	}
+
+	// borshSize returns the encoded size of a fixed-layout type, or -1 when it
+	// contains variable-length data. Options and complex enums count their
+	// largest form, which is the space an account must reserve.
+	typeDefs := make(map[string]IdlTypeDefinition, len(idl.Types))
+	for _, t := range idl.Types {
+		typeDefs[t.Name] = t
+	}
+	// Defined references may also name an account whose struct is generated
+	// from its inline fields (with Equal and Validate methods like a type).
+	for _, a := range idl.Accounts {
+		if _, ok := typeDefs[a.Name]; !ok && a.Type != nil && len(a.Type.Fields) > 0 {
+			def := IdlTypeDefinition{Name: a.Name}
+			def.Type.Kind = "struct"
+			def.Type.Fields = a.Type.Fields
+			typeDefs[a.Name] = def
+		}
+	}
+	var borshSize func(t IdlType, visiting map[string]bool) int
+	fieldsSize := func(types []IdlType, visiting map[string]bool) int {
+		total := 0
+		for _, t := range types {
+			n := borshSize(t, visiting)
+			if n < 0 {
+				return -1
+			}
+			total += n
+		}
+		return total
+	}
+	borshSize = func(t IdlType, visiting map[string]bool) int {
+		switch {
+		case t.Primitive != "":
+			return primitiveSize(t.Primitive)
+		case t.Option != nil:
+			if n := borshSize(innerType(*t.Option), visiting); n >= 0 {
+				return 1 + n
+			}
+		case t.Coption != nil:
+			if n := borshSize(innerType(*t.Coption), visiting); n >= 0 {
+				return 4 + n
+			}
+		case t.Array != nil:
+			count, ok := arraySize((*t.Array)[1])
+			if !ok {
+				if v, known := constValues[symbolicArraySize((*t.Array)[1])]; known {
+					count, ok = int(v), true
+				}
+			}
+			if n := borshSize(innerType((*t.Array)[0]), visiting); ok && n >= 0 {
+				return count * n
+			}
+		case t.Tuple != nil:
+			elems := make([]IdlType, len(t.Tuple))
+			for i, elem := range t.Tuple {
+				elems[i] = innerType(elem)
+			}
+			return fieldsSize(elems, visiting)
+		case t.Defined != nil:
+			def, ok := typeDefs[*t.Defined]
+			if !ok || visiting[def.Name] || externalTypes[def.Name] != "" {
+				return -1
+			}
+			visiting[def.Name] = true
+			defer delete(visiting, def.Name)
+			if def.Type.Kind == "enum" {
+				largest := 0
+				for _, v := range def.Type.Variants {
+					n := fieldsSize(encodedTypes(enumFields(v.Fields)), visiting)
+					if n < 0 {
+						return -1
+					}
+					largest = max(largest, n)
+				}
+				return 1 + largest
+			}
+			return fieldsSize(encodedTypes(def.Type.Fields), visiting)
+		}
+		return -1
+	}
+	accountSize := func(acc IdlAccountDefinition) int {
+		var n int
+		if acc.Type != nil && len(acc.Type.Fields) > 0 {
+			n = fieldsSize(encodedTypes(acc.Type.Fields), map[string]bool{})
+		} else {
+			n = borshSize(IdlType{Defined: &acc.Name}, map[string]bool{})
+		}
+		if n < 0 {
+			return -1
+		}
+		return discriminatorLen(acc.Discriminator) + n
+	}
+
+	// fieldCheck returns a Go statement validating the value at expr (a
+	// pointer when ptr is set), or "" when t carries no checkable constraint.
+	var fieldCheck func(expr string, ptr bool, label string, t IdlType, maxLen string) string
+	fieldCheck = func(expr string, ptr bool, label string, t IdlType, maxLen string) string {
+		val := expr
+		if ptr {
+			val = "*" + expr
+		}
+		lenCheck := ""
+		if maxLen != "" {
+			lenCheck = fmt.Sprintf("if len(%s) > %s {\nreturn fmt.Errorf(\"%s: length %%d exceeds the maximum of %%d\", len(%s), %s)\n}", val, maxLen, label, val, maxLen)
+		}
+		switch {
+		case t.Primitive == "string" || t.Primitive == "bytes":
+			return lenCheck
+		case t.Option != nil || t.Coption != nil:
+			raw := t.Option
+			if raw == nil {
+				raw = t.Coption
+			}
+			if ptr {
+				return ""
+			}
+			if inner := fieldCheck(expr, true, label, innerType(*raw), maxLen); inner != "" {
+				return fmt.Sprintf("if %s != nil {\n%s\n}", expr, inner)
+			}
+		case t.Vec != nil || t.Array != nil:
+			var elem IdlType
+			if t.Vec != nil {
+				elem = innerType(*t.Vec)
+			} else {
+				elem = innerType((*t.Array)[0])
+				lenCheck = ""
+			}
+			check := lenCheck
+			if inner := fieldCheck("e", false, label, elem, ""); inner != "" {
+				if check != "" {
+					check += "\n"
+				}
+				check += fmt.Sprintf("for _, e := range %s {\n%s\n}", val, inner)
+			}
+			return check
+		case t.Defined != nil:
+			def, ok := typeDefs[*t.Defined]
+			if !ok || externalTypes[def.Name] != "" {
+				return ""
+			}
+			if def.Type.Kind == "enum" && len(def.Type.Variants) > 0 {
+				last := prefix + toPascalCase(def.Name) + toPascalCase(def.Type.Variants[len(def.Type.Variants)-1].Name)
+				variant := val
+				if isComplexEnum(def.Type.Variants) {
+					variant = expr + ".Kind"
+				}
+				return fmt.Sprintf("if %s > %s {\nreturn fmt.Errorf(\"%s: invalid variant %%d\", %s)\n}", variant, last, label, variant)
+			}
+			if def.Type.Kind == "struct" {
+				return fmt.Sprintf("if err := %s.Validate(); err != nil {\nreturn fmt.Errorf(\"%s: %%w\", err)\n}", expr, label)
+			}
+		}
+		return ""
+	}
+	// comparable reports whether values of t can be compared with ==.
+	var comparable func(t IdlType) bool
+	comparable = func(t IdlType) bool {
+		switch {
+		case t.Primitive != "":
+			_, overridden := primitiveTypes[t.Primitive]
+			return t.Primitive != "bytes" && !overridden
+		case t.Array != nil:
+			// Arrays of unresolved size fall back to slices.
+			return !strings.HasPrefix(mapType(t), "[]") && comparable(innerType((*t.Array)[0]))
+		case t.Tuple != nil:
+			for _, elem := range t.Tuple {
+				if !comparable(innerType(elem)) {
+					return false
+				}
+			}
+			return true
+		case t.Defined != nil:
+			def, ok := typeDefs[*t.Defined]
+			return ok && externalTypes[def.Name] == "" && def.Type.Kind == "enum" && !isComplexEnum(def.Type.Variants)
+		}
+		return false
+	}
+	// equalCheck returns Go statements returning false when a and b, values
+	// of type t, differ. depth names the loop variables of nested elements.
+	var equalCheck func(a, b string, t IdlType, depth int) string
+	equalCheck = func(a, b string, t IdlType, depth int) string {
+		index := func(expr, i string) string {
+			if strings.HasPrefix(expr, "*") {
+				expr = "(" + expr + ")"
+			}
+			return expr + "[" + i + "]"
+		}
+		i := fmt.Sprintf("i%d", depth)
+		switch {
+		case comparable(t):
+			return fmt.Sprintf("if %s != %s {\nreturn false\n}", a, b)
+		case t.Primitive == "bytes" || (t.Vec != nil && innerType(*t.Vec).Primitive == "u8"):
+			return fmt.Sprintf("if !bytes.Equal(%s, %s) {\nreturn false\n}", a, b)
+		case t.Option != nil || t.Coption != nil:
+			raw := t.Option
+			if raw == nil {
+				raw = t.Coption
+			}
+			inner := innerType(*raw)
+			var check string
+			if def, ok := typeDefs[derefName(inner.Defined)]; ok && externalTypes[def.Name] == "" && (def.Type.Kind == "struct" || isComplexEnum(def.Type.Variants)) {
+				check = fmt.Sprintf("if !%s.Equal(%s) {\nreturn false\n}", a, b)
+			} else {
+				check = equalCheck("*"+a, "*"+b, inner, depth)
+			}
+			return fmt.Sprintf("if (%s == nil) != (%s == nil) {\nreturn false\n}\nif %s != nil {\n%s\n}", a, b, a, check)
+		case t.Vec != nil:
+			return fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\nfor %s := range %s {\n%s\n}", a, b, i, a, equalCheck(index(a, i), index(b, i), innerType(*t.Vec), depth+1))
+		case t.Array != nil:
+			loop := fmt.Sprintf("for %s := range %s {\n%s\n}", i, a, equalCheck(index(a, i), index(b, i), innerType((*t.Array)[0]), depth+1))
+			if strings.HasPrefix(mapType(t), "[]") {
+				return fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\n%s", a, b, loop)
+			}
+			return loop
+		case t.Map != nil:
+			k, v := fmt.Sprintf("k%d", depth), fmt.Sprintf("v%d", depth)
+			w := fmt.Sprintf("w%d", depth)
+			return fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\nfor %s, %s := range %s {\n%s, ok := %s[%s]\nif !ok {\nreturn false\n}\n%s\n}", a, b, k, v, a, w, b, k, equalCheck(v, w, innerType((*t.Map)[1]), depth+1))
+		case t.Tuple != nil:
+			checks := make([]string, len(t.Tuple))
+			for n, elem := range t.Tuple {
+				field := fmt.Sprintf(".Field%d", n)
+				checks[n] = equalCheck(a+field, b+field, innerType(elem), depth)
+			}
+			return strings.Join(checks, "\n")
+		case t.Defined != nil && typeDefs[*t.Defined].Name != "" && externalTypes[*t.Defined] == "":
+			return fmt.Sprintf("if !%s.Equal(&%s) {\nreturn false\n}", a, b)
+		}
+		return fmt.Sprintf("if !reflect.DeepEqual(%s, %s) {\nreturn false\n}", a, b)
+	}
+	newEqualMethod := func(typeName string, fields []IdlField) equalMethod {
+		m := equalMethod{TypeName: prefix + typeName}
+		for _, f := range fields {
+			name := toPascalCase(f.Name)
+			m.Checks = append(m.Checks, equalCheck("v."+name, "other."+name, f.Type, 0))
+		}
+		return m
+	}
+	// Equal falls back to reflect.DeepEqual for external and overridden types.
+	// Assertions name encoding.BinaryMarshaler for every struct with binary methods.
+	usesEncoding := false
+	if opts.Assertions {
+		for _, t := range idl.Types {
+			if t.Type.Kind == "struct" {
+				usesEncoding = true
+			}
+		}
+		for _, a := range idl.Accounts {
+			if a.Type != nil && len(a.Type.Fields) > 0 {
+				usesEncoding = true
+			}
+		}
+	}
+
+	usesReflect := false
+	if opts.Equal {
+		check := func(fields []IdlField) {
+			for _, f := range fields {
+				if strings.Contains(equalCheck("a", "b", f.Type, 0), "reflect.") {
+					usesReflect = true
+				}
+			}
+		}
+		for _, t := range idl.Types {
+			check(t.Type.Fields)
+			for _, v := range t.Type.Variants {
+				check(enumFields(v.Fields))
+			}
+		}
+		for _, a := range idl.Accounts {
+			if a.Type != nil {
+				check(a.Type.Fields)
+			}
+		}
+	}
+	newValidateMethod := func(typeName string, fields []IdlField) validateMethod {
+		m := validateMethod{TypeName: prefix + typeName}
+		for _, f := range fields {
+			maxLen := ""
+			for _, doc := range f.Docs {
+				if match := maxLenDoc.FindStringSubmatch(doc); match != nil {
+					if _, err := strconv.Atoi(match[1]); err == nil {
+						maxLen = match[1]
+					} else {
+						maxLen = constSizes[match[1]]
+					}
+					break
+				}
+			}
+			if check := fieldCheck("v."+toPascalCase(f.Name), false, f.Name, f.Type, maxLen); check != "" {
+				m.Checks = append(m.Checks, check)
+			}
+		}
+		return m
+	}
+
+	// wideIntNote documents fields holding 256-bit integers, which bin has no
+	// types for, so by default they stay raw bytes.
+	wideIntNote := func(t IdlType) string {
+		note := ""
+		walkType(t, func(t IdlType) {
+			if _, overridden := primitiveTypes[t.Primitive]; !overridden && (t.Primitive == "u256" || t.Primitive == "i256") {
+				note = t.Primitive + " values are kept as their 32 little-endian Borsh bytes."
+			}
+		})
+		return note
+	}
+	// structTag returns the struct tag of field f, bin:"-" when it is skipped.
+	structTag := func(f IdlField) string {
+		tag := fmt.Sprintf("bin:%q", tagName(f.Name, opts.TagCase)+optionTag(f.Type))
+		if fieldSkipped(f.Attrs) {
+			tag = `bin:"-"`
+		}
+		if opts.JSONTags {
+			tag += fmt.Sprintf(" json:%q", f.Name)
+		}
+		return "`" + tag + "`"
+	}
+
+	// describeField returns a Go statement writing label and the value expr
+	// of type t to b: pubkeys in base58, byte slices and arrays in hex.
+	var describeField func(expr, label string, t IdlType) string
+	describeField = func(expr, label string, t IdlType) string {
+		switch {
+		case t.Option != nil || t.Coption != nil:
+			raw := t.Option
+			if raw == nil {
+				raw = t.Coption
+			}
+			return fmt.Sprintf("if %s == nil {\nb.WriteString(%q)\n} else {\n%s\n}", expr, label+": nil", describeField("*"+expr, label, innerType(*raw)))
+		case isPubkey(t) && primitiveTypes[t.Primitive] == "":
+			if strings.HasPrefix(expr, "*") {
+				expr = "(" + expr + ")"
+			}
+			return fmt.Sprintf("fmt.Fprintf(&b, %q, %s.String())", label+": %s", expr)
+		case t.Primitive == "bytes" || (t.Vec != nil && isByte(innerType(*t.Vec))) || (t.Array != nil && isByte(innerType((*t.Array)[0]))):
+			return fmt.Sprintf("fmt.Fprintf(&b, %q, %s)", label+": %x", expr)
+		case t.Defined != nil:
+			return fmt.Sprintf("fmt.Fprintf(&b, %q, %s)", label+": %+v", expr)
+		}
+		return fmt.Sprintf("fmt.Fprintf(&b, %q, %s)", label+": %v", expr)
+	}
+	newDescribeMethod := func(typeName string, fields []IdlField) describeMethod {
+		m := describeMethod{TypeName: prefix + typeName}
+		for _, f := range fields {
+			m.Fields = append(m.Fields, describeField("v."+toPascalCase(f.Name), toPascalCase(f.Name), f.Type))
+		}
+		return m
+	}
+
+	// pdaSpec reconstructs the seed expressions and runtime parameters needed
+	// to derive the address of a PDA account.
+	pdaSpec := func(instr IdlInstruction, pda IdlPda) pdaHelper {
+		var h pdaHelper
+		seen := make(map[string]bool)
+		addParam := func(name, typ string) {
+			if !seen[name] {
+				seen[name] = true
+				h.Params = append(h.Params, name+" "+typ)
+			}
+		}
+		seedExpr := func(seed IdlSeed) string {
+			switch seed.Kind {
+			case "const":
+				switch v := seed.Value.(type) {
+				case string:
+					return fmt.Sprintf("[]byte(%q)", v)
+				case []interface{}:
+					nums := make([]int, len(v))
+					for i, n := range v {
+						f, _ := n.(float64)
+						nums[i] = int(f)
+					}
+					return "[]byte{" + intSliceToBytesLiteral(nums) + "}"
+				}
+			case "account":
+				name := toCamelCase(seed.Path)
+				addParam(name, "solana.PublicKey")
+				return name + ".Bytes()"
+			case "arg":
+				name := toCamelCase(seed.Path)
+				typ := argPathType(instr, seed.Path, idl.Types)
+				switch {
+				case isPubkey(typ):
+					addParam(name, "solana.PublicKey")
+					return name + ".Bytes()"
+				case typ.Primitive == "string":
+					addParam(name, "string")
+					return "[]byte(" + name + ")"
+				case typ.Primitive == "bytes":
+					addParam(name, "[]byte")
+					return name
+				default:
+					addParam(name, mapType(typ))
+					if !seen[name+"Seed"] {
+						seen[name+"Seed"] = true
+						h.Encoded = append(h.Encoded, name)
+					}
+					return name + "Seed"
+				}
+			}
+			return "nil"
+		}
+		for _, seed := range pda.Seeds {
+			h.Seeds = append(h.Seeds, seedExpr(seed))
+		}
+		if pda.Program != nil {
+			h.Program = "solana.PublicKeyFromBytes(" + seedExpr(*pda.Program) + ")"
+		}
+		return h
+	}
+
+	assertions := func(typeName string, ifaces ...string) string {
+		// Generic types cannot be asserted on without type arguments.
+		if !opts.Assertions || strings.Contains(typeName, "[") {
+			return ""
+		}
+		var b strings.Builder
+		b.WriteString("\n\n// Compile-time checks that " + typeName + " implements the interfaces of its methods.\nvar (\n")
+		for _, iface := range ifaces {
+			fmt.Fprintf(&b, "\t_ %s = (*%s)(nil)\n", iface, typeName)
+		}
+		b.WriteString(")")
+		return b.String()
+	}
+	funcMap := template.FuncMap{
+		"toPascalCase":           toPascalCase,
+		"toCamelCase":            toCamelCase,
+		"mapType":                mapType,
+		"intSliceToBytesLiteral": intSliceToBytesLiteral,
+		"manualDiscriminator":    manualDiscriminator,
+		"accountDiscriminatorName": func(name string) string {
+			return accountDiscriminatorName(name, opts.AccountNameCase)
+		},
+		"instructionDiscriminator": func(name string) string {
+			return manualDiscriminator(instrNamespace, instructionDiscriminatorName(name, opts.InstrNameCase))
+		},
+		"isComplexEnum": isComplexEnum,
+		"hasMap":        hasMap,
+		"typeParams":    typeParams,
+		"typeArgs":      typeArgs,
+		"astStruct": func(name string, params []IdlGenericParam, fields []IdlField) (string, error) {
+			decl := make([]astField, len(fields))
+			for i, f := range fields {
+				docs := append([]string(nil), f.Docs...)
+				if hasMap(f.Type) {
+					docs = append(docs, "Borsh encodes map entries sorted by key, so keys must be integers or strings.")
+				}
+				if note := wideIntNote(f.Type); note != "" {
+					docs = append(docs, note)
+				}
+				if fieldSkipped(f.Attrs) {
+					docs = append(docs, "Not part of the Borsh encoding.")
+				}
+				if value := fieldDefault(f.Attrs); value != "" {
+					docs = append(docs, "Defaults to "+value+".")
+				}
+				decl[i] = astField{
+					Name:    toPascalCase(f.Name),
+					Type:    mapType(f.Type),
+					Tag:     structTag(f),
+					Docs:    docs,
+					Comment: strings.TrimPrefix(sizeNote(f.Type, constSizes), " // "),
+				}
+			}
+			typeName := prefix + toPascalCase(name)
+			names := make([]string, len(params))
+			for i, p := range params {
+				names[i] = p.Name
+			}
+			return astStructDecl(typeName, typeName+" represents the struct "+name+".", names, decl)
+		},
+		"hasOptionalAccounts": hasOptionalAccounts,
+		"hasTokenAccounts":    hasTokenAccounts,
+		"isTokenAccount":      isTokenAccount,
+		"sizeNote": func(t IdlType) string {
+			return sizeNote(t, constSizes)
+		},
+		"constDecl": func(c IdlConst) (constDecl, error) {
+			return newConstDecl(c, mapType(c.Type))
+		},
+		"structTag":    structTag,
+		"wideIntNote":  wideIntNote,
+		"fieldSkipped": fieldSkipped,
+		"fieldDefault": fieldDefault,
+		"assertions":   assertions,
+		"optionCodec": func(typeName string, fields []IdlField) (string, error) {
+			code, err := optionCodec(prefix+typeName, fields, mapType)
+			if code == "" || err != nil {
+				return "", err
+			}
+			return code + assertions(prefix+typeName, "bin.EncoderDecoder"), nil
+		},
+		"binaryMethods": func(typeName string, account bool) binaryMethods {
+			return binaryMethods{TypeName: prefix + typeName, Account: account}
+		},
+		"hasMethods": func(typeName string, fields []IdlField) hasMethods {
+			m := hasMethods{TypeName: prefix + typeName}
+			for _, f := range fields {
+				if f.Type.Option != nil || f.Type.Coption != nil {
+					m.Fields = append(m.Fields, toPascalCase(f.Name))
+				}
+			}
+			return m
+		},
+		"validateMethod":   newValidateMethod,
+		"equalMethod":      newEqualMethod,
+		"describeMethod":   newDescribeMethod,
+		"enumFields":       enumFields,
+		"sumLine":          sumLine,
+		"banner":           banner,
+		"computeUnits":     computeUnits,
+		"discriminatorLen": discriminatorLen,
+		"discriminatorType": func(n int) string {
+			if opts.DiscriminatorArray {
+				return fmt.Sprintf("[%d]byte", n)
+			}
+			return "[]byte"
+		},
+		"discriminatorBytes": func(name string) string {
+			if opts.DiscriminatorArray {
+				return name + "[:]"
+			}
+			return name
+		},
+		"discriminatorMismatch": func(n int, name string) string {
+			if opts.DiscriminatorArray {
+				return fmt.Sprintf("%s != %s", toArray(n, fmt.Sprintf("data[:%d]", n)), name)
+			}
+			return fmt.Sprintf("!bytes.Equal(data[:%d], %s)", n, name)
+		},
+		"discriminatorMatch": func(n int, name string) string {
+			if opts.DiscriminatorArray {
+				return fmt.Sprintf("%s == %s", toArray(n, fmt.Sprintf("data[:%d]", n)), name)
+			}
+			return fmt.Sprintf("bytes.Equal(data[:%d], %s)", n, name)
+		},
+		"registryEntries": func() []registryEntry {
+			return registryEntries(idl, prefix)
+		},
+		"sortedErrors": sortedErrors,
+		"registryKey": func(name string) string {
+			if opts.DiscriminatorArray {
+				return name
+			}
+			return toArray(8, name)
+		},
+		"hasType":     hasType,
+		"isAccount":   isAccount,
+		"pdaSpec":     pdaSpec,
+		"accountSize": accountSize,
+	}
+
+	tmpl, err := parsedTemplate(funcMap)
+	if err != nil {
+		return nil, err
+	}
+
+	// The client is the only user of the rpc package.
+	rpcImport, jsonrpcImport := "", ""
+	if clientName != "" {
+		rpcImport = importSpec("rpc", opts.RPCImport, defaultRPCImport)
+		// jsonrpc lives under the rpc package, so it follows -rpc-import.
+		jsonrpcOverride := ""
+		if opts.RPCImport != "" {
+			jsonrpcOverride = opts.RPCImport + "/jsonrpc"
+		}
+		jsonrpcImport = importSpec("jsonrpc", jsonrpcOverride, defaultRPCImport+"/jsonrpc")
+	}
+
+	enumJSON := false
+	if opts.EnumJSON {
+		for _, t := range idl.Types {
+			if t.Type.Kind == "enum" {
+				enumJSON = true
+			}
+		}
+	}
+
+	// Minimal output only needs solana for solana.PublicKey fields.
+	solanaImport := importSpec("solana", opts.SolanaImport, defaultSolanaImport)
+	if opts.Minimal && (!usesPubkey || primitiveOverrides["pubkey"] != "") {
+		solanaImport = ""
+	}
+
+	var buf bytes.Buffer
+	dataMap := struct {
+		PackageName   string
+		ClientName    string
+		Prefix        string
+		BinImport     string
+		SolanaImport  string // empty in minimal mode when no pubkey uses solana.PublicKey
+		RPCImport     string // empty when nothing uses rpc
+		JSONRPCImport string // set with RPCImport, for New<Client>WithConfig
+		EnumJSON      bool   // enums get MarshalJSON/UnmarshalJSON
+		UsesReflect   bool   // Equal compares external types with reflect.DeepEqual
+		UsesEncoding  bool   // assertions reference encoding.BinaryMarshaler
+		SPLHelpers    bool   // the IDL takes token accounts and Options.SPLHelpers is set
+		Imports       []goImport
+		PublicKeyType string
+		InputSum      string // recorded in the header in incremental mode
+		IDLSum        string
+		Version       string
+		Options       Options
+		IDL           IDL
+	}{
+		PackageName:   opts.PackageName,
+		ClientName:    clientName,
+		Prefix:        prefix,
+		BinImport:     importSpec("bin", opts.BinImport, defaultBinImport),
+		SolanaImport:  solanaImport,
+		RPCImport:     rpcImport,
+		JSONRPCImport: jsonrpcImport,
+		EnumJSON:      enumJSON,
+		UsesReflect:   usesReflect,
+		UsesEncoding:  usesEncoding,
+		SPLHelpers:    opts.SPLHelpers && !opts.Minimal && usesSPLToken(idl),
+		Imports:       imports,
+		PublicKeyType: publicKeyType,
+		InputSum:      sum,
+		IDLSum:        idlSum,
+		Version:       version(),
+		Options:       opts,
+		IDL:           idl,
+	}
+
+	if err := tmpl.Execute(&buf, dataMap); err != nil {
+		return nil, err
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		if opts.AllowUnformatted {
+			if opts.Verbose {
+				log.Printf("Warning: Code format failed: %v. Writing unformatted code.", err)
+			}
+			return buf.Bytes(), nil
+		}
+		return nil, newFormatError(err, buf.Bytes())
+	}
+	formatted, err = pruneImports(formatted)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Verify {
+		if err := verifyCode(formatted); err != nil {
+			return nil, err
+		}
+	}
+
+	return formatted, nil
+}
+
+// FormatError is returned when the generated code is not valid Go source,
+// which usually points to a template bug or an unsupported IDL construct.
+type FormatError struct {
+	Err       error
+	Line      string // the offending generated line, if known
+	Source    []byte // the unformatted generated code
+	DebugPath string // where Source was saved, if it was
+}
+
+func newFormatError(err error, src []byte) *FormatError {
+	fe := &FormatError{Err: err, Source: src}
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		lines := strings.Split(string(src), "\n")
+		if n := list[0].Pos.Line; n > 0 && n <= len(lines) {
+			fe.Line = strings.TrimSpace(lines[n-1])
+		}
+	}
+	return fe
+}
+
+func (e *FormatError) Error() string {
+	msg := "generated code is not valid Go: " + e.Err.Error()
+	if e.Line != "" {
+		msg += fmt.Sprintf("\n  offending line: %s", e.Line)
+	}
+	if e.DebugPath != "" {
+		msg += fmt.Sprintf("\n  unformatted output saved to %s", e.DebugPath)
+	}
+	return msg
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+// stdioPath is the path value that selects stdin for input and stdout for output.
+const stdioPath = "-"
+
+// fetchTimeout bounds fetching an IDL over HTTP.
+const fetchTimeout = 30 * time.Second
+
+// isURL reports whether path is an http:// or https:// URL.
+func isURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// readInput reads the IDL from a file, from an http(s) URL, or from stdin
+// when path is "-". A comma-separated list of paths is read as one IDL
+// merging them all.
+func readInput(path string) ([]byte, error) {
+	if strings.Contains(path, ",") {
+		return readMerged(strings.Split(path, ","))
+	}
+	if path == stdioPath {
+		return io.ReadAll(os.Stdin)
+	}
+	if isURL(path) {
+		return fetchURL(path)
+	}
+	return os.ReadFile(path)
+}
+
+// fetchURL downloads an IDL, failing on any non-200 response.
+func fetchURL(rawURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IDL: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch IDL from %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// writeOutput writes generated code to a file, or to stdout when path is "-".
+func writeOutput(path string, data []byte) error {
+	if path == stdioPath {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// --- Template ---
+
+var (
+	baseTemplateOnce sync.Once
+	baseTemplate     *template.Template
+	baseTemplateErr  error
+)
+
+// parsedTemplate returns a clone of the parsed goTemplate bound to funcMap.
+// The template is parsed once; each generation clones it and installs its own
+// funcs, since closures such as mapType capture per-IDL state like the prefix.
+func parsedTemplate(funcMap template.FuncMap) (*template.Template, error) {
+	baseTemplateOnce.Do(func() {
+		baseTemplate, baseTemplateErr = template.New("idl").Funcs(funcMap).Parse(goTemplate)
+	})
+	if baseTemplateErr != nil {
+		return nil, baseTemplateErr
+	}
+	tmpl, err := baseTemplate.Clone()
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Funcs(funcMap), nil
+}
+
+const goTemplate = `
+{{- define "field" }}
+	{{- range .Docs }}// {{ . }}
+	{{ end }}
+	{{- if hasMap .Type }}// Borsh encodes map entries sorted by key, so keys must be integers or strings.
+	{{ end }}
+	{{- with wideIntNote .Type }}// {{ . }}
+	{{ end }}
+	{{- if fieldSkipped .Attrs }}// Not part of the Borsh encoding.
+	{{ end }}
+	{{- with fieldDefault .Attrs }}// Defaults to {{ . }}.
+	{{ end }}
+	{{- .Name | toPascalCase }} {{ mapType .Type }} {{ structTag . }}{{ sizeNote .Type }}
+{{- end -}}
+{{- define "docLines" }}
+	{{- if . }}
+//
+	{{- range . }}
+// {{ . }}
+	{{- end }}
+	{{- end }}
+{{- end -}}
+{{- define "equalMethod" }}
+
+// Equal reports whether v and other hold the same values.
+func (v *{{ .TypeName }}) Equal(other *{{ .TypeName }}) bool {
+	if v == nil || other == nil {
+		return v == other
+	}
+	{{- range .Checks }}
+	{{ . }}
+	{{- end }}
+	return true
+}
+{{- end -}}
+{{- define "describeMethod" }}
+
+// Describe renders {{ .TypeName }} for logs, with pubkeys in base58 and byte slices in hex.
+func (v {{ .TypeName }}) Describe() string {
+	var b strings.Builder
+	b.WriteString("{{ .TypeName }}{")
+	{{- range $i, $field := .Fields }}
+	{{- if $i }}
+	b.WriteString(", ")
+	{{- end }}
+	{{ $field }}
+	{{- end }}
+	b.WriteString("}")
+	return b.String()
+}
+{{- end -}}
+{{- define "validateMethod" }}
+
+// Validate checks {{ .TypeName }} against the constraints the IDL describes.
+func (v {{ .TypeName }}) Validate() error {
+	{{- range .Checks }}
+	{{ . }}
+	{{- end }}
+	return nil
+}
+{{- end -}}
+{{- define "hasMethods" }}
+{{- $typeName := .TypeName }}
+{{- range .Fields }}
+
+// Has{{ . }} reports whether the optional {{ . }} field is set.
+func (v {{ $typeName }}) Has{{ . }}() bool {
+	return v.{{ . }} != nil
+}
+{{- end }}
+{{- end -}}
+{{- define "binaryMethods" }}
+
+// MarshalBinary encodes {{ .TypeName }} with Borsh{{ if .Account }}, prefixed by the account discriminator{{ end }}.
+func (v {{ .TypeName }}) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	{{- if .Account }}
+	buf.Write({{ discriminatorBytes (print .TypeName "Discriminator") }})
+	{{- end }}
+	if err := bin.NewBorshEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes {{ .TypeName }} from Borsh data{{ if .Account }}, checking the account discriminator{{ end }}.
+func (v *{{ .TypeName }}) UnmarshalBinary(data []byte) error {
+	{{- if .Account }}
+	decoded, err := Decode{{ .TypeName }}(data)
+	if err != nil {
+		return err
+	}
+	*v = *decoded
+	return nil
+	{{- else }}
+	return bin.NewBorshDecoder(data).Decode(v)
+	{{- end }}
+}
+{{- assertions .TypeName "encoding.BinaryMarshaler" "encoding.BinaryUnmarshaler" }}
+{{- end -}}
+{{ banner .Options.Header }}// Code generated by idlgen {{ .Version }}. DO NOT EDIT.
+// Program: {{ .IDL.Name }}
+// IDL sha256: {{ .IDLSum }}
+{{- if .InputSum }}
+{{ sumLine .InputSum }}
+{{- end }}
+
+package {{ .PackageName }}
+
+import (
+	"bytes"
+	{{- if and .ClientName (or .IDL.Instructions .IDL.Accounts) }}
+	"context"
+	"errors"
+	{{- end }}
+	{{- if .UsesEncoding }}
+	"encoding"
+	{{- end }}
+	{{- if .EnumJSON }}
+	"encoding/json"
+	{{- end }}
+	{{- if .UsesReflect }}
+	"reflect"
+	{{- end }}
+	"fmt"
+	{{- if .ClientName }}
+	"net/http"
+	{{- end }}
+	{{- if .Options.Describe }}
+	"strings"
+	{{- end }}
+	{{- if .ClientName }}
+	"time"
+	{{- end }}
+
+	{{ .BinImport }}
+	{{- if .SolanaImport }}
+	{{ .SolanaImport }}
+	{{- end }}
+	{{- if .RPCImport }}
+	{{ .RPCImport }}
+	{{ .JSONRPCImport }}
+	{{- end }}
+	{{- range .Imports }}
+	{{ if .Alias }}{{ .Alias }} {{ end }}"{{ .Path }}"
+	{{- end }}
+)
+
+{{- if .Options.Minimal }}
+
+// ProgramAddress is the base58 address of the program.
+const {{ .Prefix }}ProgramAddress = "{{ .IDL.Address }}"
+{{- else }}
+
+// ProgramID is the public key of the program.
+var {{ .Prefix }}ProgramID = solana.MustPublicKeyFromBase58("{{ .IDL.Address }}")
+{{- end }}
+{{- with .IDL.Metadata }}
+{{- if .Version }}
+
+// {{ $.Prefix }}Version is the program version declared in the IDL metadata.
+const {{ $.Prefix }}Version = {{ printf "%q" .Version }}
+{{- end }}
+{{- if .Spec }}
+
+// {{ $.Prefix }}Spec is the IDL specification version the IDL follows.
+const {{ $.Prefix }}Spec = {{ printf "%q" .Spec }}
+{{- end }}
+{{- if .Repository }}
+
+// {{ $.Prefix }}Repository is the source repository declared in the IDL metadata.
+const {{ $.Prefix }}Repository = {{ printf "%q" .Repository }}
+{{- end }}
+{{- end }}
+
+// --- Constants ---
+{{- range .IDL.Constants }}
+{{- $decl := constDecl . }}
+
+// {{ $.Prefix }}{{ .Name | toPascalCase }} is the program constant {{ .Name }}.
+{{ $decl.Keyword }} {{ $.Prefix }}{{ .Name | toPascalCase }}{{ if $decl.Type }} {{ $decl.Type }}{{ end }} = {{ $decl.Value }}
+{{- end }}
+
+// --- Errors ---
+{{- if .IDL.Errors }}
+
+// {{ .Prefix }}Error is a custom error defined by the program.
+type {{ .Prefix }}Error struct {
+	Code    int
+	Name    string
+	Message string
+}
+
+// Error implements the error interface.
+func (e *{{ .Prefix }}Error) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Name, e.Code, e.Message)
+}
+{{- range .IDL.Errors }}
+
+// Err{{ $.Prefix }}{{ .Name | toPascalCase }} represents the error {{ .Name }}.
+var Err{{ $.Prefix }}{{ .Name | toPascalCase }} = &{{ $.Prefix }}Error{Code: {{ .Code }}, Name: {{ printf "%q" .Name }}, Message: {{ printf "%q" .Message }}}
+{{- end }}
+
+var {{ .Prefix | toCamelCase }}ErrorsByCode = map[int]error{
+	{{- range sortedErrors .IDL.Errors }}
+	{{ .Code }}: Err{{ $.Prefix }}{{ .Name | toPascalCase }},
+	{{- end }}
+}
+
+// {{ .Prefix }}ErrorByCode returns the program error for a custom error code, or nil if the code is unknown.
+func {{ .Prefix }}ErrorByCode(code int) error {
+	if err, ok := {{ .Prefix | toCamelCase }}ErrorsByCode[code]; ok {
+		return err
+	}
+	return nil
+}
+{{- end }}
+
+// --- Types ---
+{{- range .IDL.Types }}
+{{ $typeName := .Name | toPascalCase }}
+{{- $typeArgs := typeArgs .Generics }}
+{{- if eq .Type.Kind "struct" }}
+{{- if $.Options.AST }}
+{{ astStruct .Name .Generics .Type.Fields }}
+{{- else }}
+// {{ $.Prefix }}{{ $typeName }} represents the struct {{ .Name }}.
+type {{ $.Prefix }}{{ $typeName }}{{ typeParams .Generics }} struct {
+	{{- range .Type.Fields }}
+	{{ template "field" . }}
+	{{- end }}
+}
+{{- end }}
+{{- if $.Options.Constructors }}
+
+// New{{ $.Prefix }}{{ $typeName }} builds a value of type {{ $.Prefix }}{{ $typeName }} from its fields in IDL order.
+func New{{ $.Prefix }}{{ $typeName }}{{ typeParams .Generics }}(
+	{{- range .Type.Fields }}
+	{{ .Name | toCamelCase }} {{ mapType .Type }},
+	{{- end }}
+) {{ $.Prefix }}{{ $typeName }}{{ $typeArgs }} {
+	return {{ $.Prefix }}{{ $typeName }}{{ $typeArgs }}{
+		{{- range .Type.Fields }}
+		{{ .Name | toPascalCase }}: {{ .Name | toCamelCase }},
+		{{- end }}
+	}
+}
+{{- end }}
+{{- $receiver := print $typeName $typeArgs }}
+{{- if $.Options.OptionHelpers }}
+{{- template "hasMethods" (hasMethods $receiver .Type.Fields) }}
+{{- end }}
+{{- optionCodec $receiver .Type.Fields }}
+{{- template "binaryMethods" (binaryMethods $receiver (isAccount .Name)) }}
+{{- if $.Options.Equal }}
+{{- template "equalMethod" (equalMethod $receiver .Type.Fields) }}
+{{- end }}
+{{- if $.Options.Validate }}
+{{- template "validateMethod" (validateMethod $receiver .Type.Fields) }}
+{{- end }}
+{{- if and $.Options.Describe (isAccount .Name) }}
+{{- template "describeMethod" (describeMethod $typeName .Type.Fields) }}
+{{- end }}
+{{- else if eq .Type.Kind "enum" }}
+{{- if isComplexEnum .Type.Variants }}
+// {{ $.Prefix }}{{ $typeName }} represents the enum {{ .Name }}.
+// Kind holds the active variant; only its payload, if any, is encoded.
+type {{ $.Prefix }}{{ $typeName }} struct {
+	Kind bin.BorshEnum
+	{{- range .Type.Variants }}
+	{{- if .Fields }}
+	{{ .Name | toPascalCase }} *{{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}Variant
+	{{- end }}
+	{{- end }}
+}
+
+// Variants of {{ $.Prefix }}{{ $typeName }}, in Borsh tag order.
+const (
+	{{- range $i, $v := .Type.Variants }}
+	{{ $.Prefix }}{{ $typeName }}{{ $v.Name | toPascalCase }}{{ if eq $i 0 }} bin.BorshEnum = iota{{ end }}
+	{{- end }}
+)
+
+// String returns the IDL name of the active variant.
+func (e {{ $.Prefix }}{{ $typeName }}) String() string {
+	switch e.Kind {
+	{{- range .Type.Variants }}
+	case {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}:
+		return "{{ .Name }}"
+	{{- end }}
+	default:
+		return fmt.Sprintf("Unknown(%d)", e.Kind)
+	}
+}
+
+// MarshalWithEncoder writes the variant index followed by the payload of the active variant.
+func (e {{ $.Prefix }}{{ $typeName }}) MarshalWithEncoder(encoder *bin.Encoder) error {
+	if err := encoder.WriteUint8(uint8(e.Kind)); err != nil {
+		return err
+	}
+	switch e.Kind {
+	{{- range .Type.Variants }}
+	case {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}:
+		{{- if .Fields }}
+		if e.{{ .Name | toPascalCase }} == nil {
+			return fmt.Errorf("{{ $.Prefix }}{{ $typeName }}: variant {{ .Name }} has no payload")
+		}
+		return encoder.Encode(e.{{ .Name | toPascalCase }})
+		{{- else }}
+		return nil
+		{{- end }}
+	{{- end }}
+	default:
+		return fmt.Errorf("unknown {{ $.Prefix }}{{ $typeName }} variant %d", e.Kind)
+	}
+}
+
+// UnmarshalWithDecoder reads the variant index and the payload of that variant.
+func (e *{{ $.Prefix }}{{ $typeName }}) UnmarshalWithDecoder(decoder *bin.Decoder) error {
+	kind, err := decoder.ReadUint8()
+	if err != nil {
+		return err
+	}
+	*e = {{ $.Prefix }}{{ $typeName }}{Kind: bin.BorshEnum(kind)}
+	switch e.Kind {
+	{{- range .Type.Variants }}
+	case {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}:
+		{{- if .Fields }}
+		e.{{ .Name | toPascalCase }} = new({{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}Variant)
+		return decoder.Decode(e.{{ .Name | toPascalCase }})
+		{{- else }}
+		return nil
+		{{- end }}
+	{{- end }}
+	default:
+		return fmt.Errorf("unknown {{ $.Prefix }}{{ $typeName }} variant %d", e.Kind)
+	}
+}
+{{- if $.EnumJSON }}
+{{- assertions (print $.Prefix $typeName) "fmt.Stringer" "bin.EncoderDecoder" "json.Marshaler" "json.Unmarshaler" }}
+{{- else }}
+{{- assertions (print $.Prefix $typeName) "fmt.Stringer" "bin.EncoderDecoder" }}
+{{- end }}
+{{- if $.EnumJSON }}
+
+// MarshalJSON encodes the active variant by its IDL name, as "Name" or {"Name": {...}} when it has fields.
+func (e {{ $.Prefix }}{{ $typeName }}) MarshalJSON() ([]byte, error) {
+	switch e.Kind {
+	{{- range .Type.Variants }}
+	case {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}:
+		{{- if .Fields }}
+		return json.Marshal(map[string]interface{}{"{{ .Name }}": e.{{ .Name | toPascalCase }}})
+		{{- else }}
+		return json.Marshal("{{ .Name }}")
+		{{- end }}
+	{{- end }}
+	default:
+		return nil, fmt.Errorf("unknown {{ $.Prefix }}{{ $typeName }} variant %d", e.Kind)
+	}
+}
+
+// UnmarshalJSON decodes a variant encoded by MarshalJSON.
+func (e *{{ $.Prefix }}{{ $typeName }}) UnmarshalJSON(data []byte) error {
+	var name string
+	var fields json.RawMessage
+	if err := json.Unmarshal(data, &name); err != nil {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		if len(m) != 1 {
+			return fmt.Errorf("expected a single {{ $.Prefix }}{{ $typeName }} variant, got %d", len(m))
+		}
+		for k, v := range m {
+			name, fields = k, v
+		}
+	}
+	*e = {{ $.Prefix }}{{ $typeName }}{}
+	switch name {
+	{{- range .Type.Variants }}
+	case "{{ .Name }}":
+		e.Kind = {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}
+		{{- if .Fields }}
+		if fields != nil {
+			e.{{ .Name | toPascalCase }} = new({{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}Variant)
+			return json.Unmarshal(fields, e.{{ .Name | toPascalCase }})
+		}
+		{{- end }}
+	{{- end }}
+	default:
+		return fmt.Errorf("unknown {{ $.Prefix }}{{ $typeName }} variant %q", name)
+	}
+	return nil
+}
+{{- end }}
+{{- range .Type.Variants }}
+{{- if .Fields }}
+
+// {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}Variant represents the variant {{ .Name }} of enum {{ $typeName }}.
+type {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}Variant struct {
+	{{- range enumFields .Fields }}
+	{{ template "field" . }}
+	{{- end }}
+}
+{{- optionCodec (print $typeName (.Name | toPascalCase) "Variant") (enumFields .Fields) }}
+{{- if $.Options.Equal }}
+{{- template "equalMethod" (equalMethod (print $typeName (.Name | toPascalCase) "Variant") (enumFields .Fields)) }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if $.Options.Equal }}
+
+// Equal reports whether v and other hold the same variant and values.
+func (v *{{ $.Prefix }}{{ $typeName }}) Equal(other *{{ $.Prefix }}{{ $typeName }}) bool {
+	if v == nil || other == nil {
+		return v == other
+	}
+	if v.Kind != other.Kind {
+		return false
+	}
+	switch v.Kind {
+	{{- range .Type.Variants }}
+	{{- if .Fields }}
+	case {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}:
+		return v.{{ .Name | toPascalCase }}.Equal(other.{{ .Name | toPascalCase }})
+	{{- end }}
+	{{- end }}
+	}
+	return true
+}
+{{- end }}
+{{- else }}
+// {{ $.Prefix }}{{ $typeName }} represents the enum {{ .Name }}.
+type {{ $.Prefix }}{{ $typeName }} bin.BorshEnum
+
+// Variants of {{ $.Prefix }}{{ $typeName }}, in Borsh tag order.
+const (
+	{{- range $i, $v := .Type.Variants }}
+	{{ $.Prefix }}{{ $typeName }}{{ $v.Name | toPascalCase }}{{ if eq $i 0 }} {{ $.Prefix }}{{ $typeName }} = iota{{ end }}
+	{{- end }}
+)
+
+// String returns the IDL name of the variant.
+func (e {{ $.Prefix }}{{ $typeName }}) String() string {
+	switch e {
+	{{- range .Type.Variants }}
+	case {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}:
+		return "{{ .Name }}"
+	{{- end }}
+	default:
+		return fmt.Sprintf("Unknown(%d)", e)
+	}
+}
+{{- if $.EnumJSON }}
+{{- assertions (print $.Prefix $typeName) "fmt.Stringer" "json.Marshaler" "json.Unmarshaler" }}
+{{- else }}
+{{- assertions (print $.Prefix $typeName) "fmt.Stringer" }}
+{{- end }}
+{{- if $.EnumJSON }}
+
+// MarshalJSON encodes the variant by its IDL name.
+func (e {{ $.Prefix }}{{ $typeName }}) MarshalJSON() ([]byte, error) {
+	switch e {
+	{{- range .Type.Variants }}
+	case {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}:
+		return json.Marshal("{{ .Name }}")
+	{{- end }}
+	default:
+		return nil, fmt.Errorf("unknown {{ $.Prefix }}{{ $typeName }} variant %d", e)
+	}
+}
+
+// UnmarshalJSON decodes a variant from its IDL name.
+func (e *{{ $.Prefix }}{{ $typeName }}) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	switch name {
+	{{- range .Type.Variants }}
+	case "{{ .Name }}":
+		*e = {{ $.Prefix }}{{ $typeName }}{{ .Name | toPascalCase }}
+	{{- end }}
+	default:
+		return fmt.Errorf("unknown {{ $.Prefix }}{{ $typeName }} variant %q", name)
+	}
+	return nil
+}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
 
 // --- Accounts ---
 {{- range .IDL.Accounts }}
 {{ $accName := .Name | toPascalCase }}
 // {{ $.Prefix }}{{ $accName }}Discriminator is the discriminator for the account {{ .Name }}.
-var {{ $.Prefix }}{{ $accName }}Discriminator = []byte{ {{ if .Discriminator }}{{ intSliceToBytesLiteral .Discriminator }}{{ else }}{{ manualDiscriminator "account" .Name }}{{ end }} }
+var {{ $.Prefix }}{{ $accName }}Discriminator = {{ discriminatorType (discriminatorLen .Discriminator) }}{ {{ if .Discriminator }}{{ intSliceToBytesLiteral .Discriminator }}{{ else }}{{ manualDiscriminator "account" (accountDiscriminatorName .Name) }}{{ end }} }
+
+{{- if and .Type .Type.Fields }}
+
+// {{ $.Prefix }}{{ $accName }} represents the account {{ .Name }}.
+type {{ $.Prefix }}{{ $accName }} struct {
+	{{- range .Type.Fields }}
+	{{ template "field" . }}
+	{{- end }}
+}
+{{- if $.Options.OptionHelpers }}
+{{- template "hasMethods" (hasMethods $accName .Type.Fields) }}
+{{- end }}
+{{- optionCodec $accName .Type.Fields }}
+{{- template "binaryMethods" (binaryMethods $accName true) }}
+{{- if $.Options.Equal }}
+{{- template "equalMethod" (equalMethod $accName .Type.Fields) }}
+{{- end }}
+{{- if $.Options.Validate }}
+{{- template "validateMethod" (validateMethod $accName .Type.Fields) }}
+{{- end }}
+{{- if $.Options.Describe }}
+{{- template "describeMethod" (describeMethod $accName .Type.Fields) }}
+{{- end }}
+{{- else if hasType .Name }}
+
+// Note: The struct definition for account "{{ .Name }}" is {{ $.Prefix }}{{ $accName }}, generated in the Types section.
+{{- end }}
+{{- $size := accountSize . }}
+{{- if ge $size 0 }}
+
+// {{ $.Prefix }}{{ $accName }}Size is the space in bytes of a {{ .Name }} account, discriminator included.
+const {{ $.Prefix }}{{ $accName }}Size = {{ $size }}
+{{- end }}
+{{- if or (and .Type .Type.Fields) (hasType .Name) }}
+
+// Decode{{ $.Prefix }}{{ $accName }} decodes raw account data into {{ $.Prefix }}{{ $accName }}, checking the discriminator first.
+{{- $n := discriminatorLen .Discriminator }}
+func Decode{{ $.Prefix }}{{ $accName }}(data []byte) (*{{ $.Prefix }}{{ $accName }}, error) {
+	if len(data) < {{ $n }} || {{ discriminatorMismatch $n (print $.Prefix $accName "Discriminator") }} {
+		actual := data
+		if len(actual) > {{ $n }} {
+			actual = actual[:{{ $n }}]
+		}
+		return nil, fmt.Errorf("invalid discriminator for account {{ .Name }}: expected %x, got %x", {{ $.Prefix }}{{ $accName }}Discriminator, actual)
+	}
+	acc := new({{ $.Prefix }}{{ $accName }})
+	if err := bin.NewBorshDecoder(data[{{ $n }}:]).Decode(acc); err != nil {
+		return nil, fmt.Errorf("failed to decode account {{ .Name }}: %w", err)
+	}
+	return acc, nil
+}
+{{- end }}
+{{- if $.RPCImport }}
+
+// {{ $.Prefix }}{{ $accName }}Filters returns GetProgramAccounts filters matching {{ .Name }} accounts by discriminator.
+func {{ $.Prefix }}{{ $accName }}Filters() []rpc.RPCFilter {
+	return []rpc.RPCFilter{
+		{
+			Memcmp: &rpc.RPCFilterMemcmp{
+				Offset: 0,
+				Bytes:  solana.Base58({{ discriminatorBytes (print $.Prefix $accName "Discriminator") }}),
+			},
+		},
+	}
+}
+
+// Check{{ $.Prefix }}{{ $accName }}Owner returns an error unless acct is owned by the program, guarding against decoding spoofed {{ .Name }} accounts.
+func Check{{ $.Prefix }}{{ $accName }}Owner(acct *rpc.Account) error {
+	if acct == nil {
+		return fmt.Errorf("account {{ .Name }} is missing")
+	}
+	if !acct.Owner.Equals({{ $.Prefix }}ProgramID) {
+		return fmt.Errorf("account {{ .Name }} is owned by %s, not the {{ $.IDL.Name }} program", acct.Owner)
+	}
+	return nil
+}
+{{- end }}
+{{- end }}
+{{- if .SPLHelpers }}
+
+// --- SPL Helpers ---
+
+// Find{{ .Prefix }}AssociatedTokenAddress derives the associated token account of owner for mint.
+func Find{{ .Prefix }}AssociatedTokenAddress(owner, mint solana.PublicKey) (solana.PublicKey, uint8, error) {
+	return solana.FindAssociatedTokenAddress(owner, mint)
+}
+{{- end }}
+
+// --- Events ---
+{{- range .IDL.Events }}
+{{ $eventName := .Name | toPascalCase }}
+// {{ $.Prefix }}Event{{ $eventName }}Discriminator is the discriminator for the event {{ .Name }}.
+var {{ $.Prefix }}Event{{ $eventName }}Discriminator = {{ discriminatorType (discriminatorLen .Discriminator) }}{ {{ if .Discriminator }}{{ intSliceToBytesLiteral .Discriminator }}{{ else }}{{ manualDiscriminator "event" .Name }}{{ end }} }
+{{- if .Fields }}
 
-// Note: The struct definition for account "{{ .Name }}" is generated in the Types section.
+// {{ $.Prefix }}{{ $eventName }} represents the event {{ .Name }}.
+type {{ $.Prefix }}{{ $eventName }} struct {
+	{{- range .Fields }}
+	{{ template "field" . }}
+	{{- end }}
+}
+{{- optionCodec $eventName .Fields }}
+{{- else if hasType .Name }}
+
+// Note: The struct definition for event "{{ .Name }}" is {{ $.Prefix }}{{ $eventName }}, generated in the Types section.
+{{- end }}
+{{- if or .Fields (hasType .Name) }}
+
+// Decode{{ $.Prefix }}{{ $eventName }} decodes event data (as found in "Program data:" logs) into {{ $.Prefix }}{{ $eventName }}, checking the discriminator first.
+{{- $n := discriminatorLen .Discriminator }}
+func Decode{{ $.Prefix }}{{ $eventName }}(data []byte) (*{{ $.Prefix }}{{ $eventName }}, error) {
+	if len(data) < {{ $n }} || {{ discriminatorMismatch $n (print $.Prefix "Event" $eventName "Discriminator") }} {
+		actual := data
+		if len(actual) > {{ $n }} {
+			actual = actual[:{{ $n }}]
+		}
+		return nil, fmt.Errorf("invalid discriminator for event {{ .Name }}: expected %x, got %x", {{ $.Prefix }}Event{{ $eventName }}Discriminator, actual)
+	}
+	event := new({{ $.Prefix }}{{ $eventName }})
+	if err := bin.NewBorshDecoder(data[{{ $n }}:]).Decode(event); err != nil {
+		return nil, fmt.Errorf("failed to decode event {{ .Name }}: %w", err)
+	}
+	return event, nil
+}
+{{- end }}
 {{- end }}
 
 // --- Instructions ---
@@ -376,33 +3129,159 @@ var {{ $.Prefix }}{{ $accName }}Discriminator = []byte{ {{ if .Discriminator }}{
 {{ $instrName := .Name | toPascalCase }}
 
 // {{ $.Prefix }}{{ $instrName }}Discriminator is the discriminator for instruction {{ .Name }}.
-var {{ $.Prefix }}{{ $instrName }}Discriminator = []byte{ {{ if .Discriminator }}{{ intSliceToBytesLiteral .Discriminator }}{{ else }}{{ manualDiscriminator "global" .Name }}{{ end }} }
+var {{ $.Prefix }}{{ $instrName }}Discriminator = {{ discriminatorType (discriminatorLen .Discriminator) }}{ {{ if .Discriminator }}{{ intSliceToBytesLiteral .Discriminator }}{{ else }}{{ instructionDiscriminator .Name }}{{ end }} }
+{{- $computeUnits := computeUnits .Docs }}
+{{- if and $.Options.ComputeHints $computeUnits }}
+
+// {{ $.Prefix }}{{ $instrName }}ComputeUnits is the compute unit estimate documented for instruction {{ .Name }},
+// e.g. for a ComputeBudget SetComputeUnitLimit instruction.
+const {{ $.Prefix }}{{ $instrName }}ComputeUnits = {{ $computeUnits }}
+{{- end }}
 
 // {{ $.Prefix }}{{ $instrName }}Args represents the arguments for instruction {{ .Name }}.
-type {{ $.Prefix }}{{ $instrName }}Args struct {
+type {{ $.Prefix }}{{ $instrName }}Args {{ if .Args }}struct {
 	{{- range .Args }}
-	{{ .Name | toPascalCase }} {{ mapType .Type }} ` + "`" + `bin:"{{ .Name }}"` + "`" + `
+	{{ template "field" . }}
 	{{- end }}
-}
+}{{ else }}struct{}{{ end }}
+{{- optionCodec (print $instrName "Args") .Args }}
+
+{{- if $.Options.Validate }}
+{{- template "validateMethod" (validateMethod (print $instrName "Args") .Args) }}
+{{- end }}
 
 // {{ $.Prefix }}{{ $instrName }}Accounts represents the accounts for instruction {{ .Name }}.
-type {{ $.Prefix }}{{ $instrName }}Accounts struct {
+type {{ $.Prefix }}{{ $instrName }}Accounts {{ if .Accounts }}struct {
 	{{- range .Accounts }}
-	{{ .Name | toPascalCase }} solana.PublicKey
+	{{ .Name | toPascalCase }} {{ if .Optional }}*{{ end }}{{ $.PublicKeyType }}
+	{{- end }}
+}{{ else }}struct{}{{ end }}
+{{- if .Accounts }}
+
+// Positions of the {{ .Name }} accounts in the instruction's account list.
+{{- if hasOptionalAccounts .Accounts }}
+// Optional accounts are left out when unset, shifting the accounts after them.
+{{- end }}
+const (
+	{{- range $i, $a := .Accounts }}
+	{{ $.Prefix }}{{ $instrName }}AccountIndex{{ $a.Name | toPascalCase }} = {{ $i }}
+	{{- end }}
+)
+{{- end }}
+
+{{- $instr := . }}
+{{- if $.Options.Minimal }}
+
+// Encode{{ $.Prefix }}{{ $instrName }}Args encodes the instruction data for {{ .Name }}: the discriminator followed by the Borsh-encoded args.
+func Encode{{ $.Prefix }}{{ $instrName }}Args(args {{ $.Prefix }}{{ $instrName }}Args) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.Write({{ discriminatorBytes (print $.Prefix $instrName "Discriminator") }})
+	{{- if .Args }}
+	if err := bin.NewBorshEncoder(buf).Encode(args); err != nil {
+		return nil, fmt.Errorf("failed to encode args: %w", err)
+	}
+	{{- end }}
+	return buf.Bytes(), nil
+}
+{{- else }}
+{{- range .Accounts }}
+{{- if .Pda }}
+{{- $pda := pdaSpec $instr .Pda }}
+
+// Find{{ $.Prefix }}{{ $instrName }}{{ .Name | toPascalCase }}Address derives the PDA for account {{ .Name }} of instruction {{ $instr.Name }}.
+func Find{{ $.Prefix }}{{ $instrName }}{{ .Name | toPascalCase }}Address({{ range $i, $p := $pda.Params }}{{ if $i }}, {{ end }}{{ $p }}{{ end }}) (solana.PublicKey, uint8, error) {
+	{{- range $pda.Encoded }}
+	{{ . }}Seed, err := bin.MarshalBorsh({{ . }})
+	if err != nil {
+		return solana.PublicKey{}, 0, err
+	}
 	{{- end }}
+	return solana.FindProgramAddress([][]byte{
+		{{- range $pda.Seeds }}
+		{{ . }},
+		{{- end }}
+	}, {{ if $pda.Program }}{{ $pda.Program }}{{ else }}{{ $.Prefix }}ProgramID{{ end }})
 }
+{{- end }}
+{{- end }}
+
+{{- if $.Options.CheckAccounts }}
+
+// New{{ $.Prefix }}{{ $instrName }}Instruction creates a new instruction for {{ .Name }}.
+// It returns an error if a required account is the zero public key, except for
+// accounts with a fixed IDL address such as the System Program, which is all zeros.
+// Remaining accounts, e.g. dynamic routes, are appended after the IDL accounts.
+{{- template "docLines" .Docs }}
+func New{{ $.Prefix }}{{ $instrName }}Instruction(
+	args {{ $.Prefix }}{{ $instrName }}Args,
+	accounts {{ $.Prefix }}{{ $instrName }}Accounts,
+	remaining ...*solana.AccountMeta,
+) (solana.Instruction, error) {
+	{{- range .Accounts }}
+	{{- if not (or .Optional .Address) }}
+	if accounts.{{ .Name | toPascalCase }}.IsZero() {
+		return nil, fmt.Errorf("{{ $instr.Name }}: required account {{ .Name }} is not set")
+	}
+	{{- end }}
+	{{- end }}
+	buf := new(bytes.Buffer)
+	buf.Write({{ discriminatorBytes (print $.Prefix $instrName "Discriminator") }})
+	{{- if .Args }}
+	encoder := bin.NewBorshEncoder(buf)
+	if err := encoder.Encode(args); err != nil {
+		return nil, fmt.Errorf("failed to encode args: %w", err)
+	}
+	{{- end }}
+{{- else }}
 
 // New{{ $.Prefix }}{{ $instrName }}Instruction creates a new instruction for {{ .Name }}.
+// Remaining accounts, e.g. dynamic routes, are appended after the IDL accounts.
+{{- template "docLines" .Docs }}
 func New{{ $.Prefix }}{{ $instrName }}Instruction(
 	args {{ $.Prefix }}{{ $instrName }}Args,
 	accounts {{ $.Prefix }}{{ $instrName }}Accounts,
+	remaining ...*solana.AccountMeta,
 ) solana.Instruction {
 	buf := new(bytes.Buffer)
-	buf.Write({{ $.Prefix }}{{ $instrName }}Discriminator)
+	buf.Write({{ discriminatorBytes (print $.Prefix $instrName "Discriminator") }})
+	{{- if .Args }}
 	encoder := bin.NewBorshEncoder(buf)
 	if err := encoder.Encode(args); err != nil {
 		panic(fmt.Errorf("failed to encode args: %w", err))
 	}
+	{{- end }}
+{{- end }}
+
+	{{- if not .Accounts }}
+
+	return solana.NewInstruction(
+		{{ $.Prefix }}ProgramID,
+		remaining,
+		buf.Bytes(),
+	){{ if $.Options.CheckAccounts }}, nil{{ end }}
+}
+	{{- else }}
+	{{- if hasOptionalAccounts .Accounts }}
+
+	keys := make([]*solana.AccountMeta, 0, {{ len .Accounts }}+len(remaining))
+	{{- range .Accounts }}
+	{{- if .Optional }}
+	if accounts.{{ .Name | toPascalCase }} != nil {
+		keys = append(keys, &solana.AccountMeta{
+			PublicKey:  *accounts.{{ .Name | toPascalCase }},
+			IsSigner:   {{ .IsSigner }},
+			IsWritable: {{ .IsWritable }},
+		})
+	}
+	{{- else }}
+	keys = append(keys, &solana.AccountMeta{
+		PublicKey:  accounts.{{ .Name | toPascalCase }},
+		IsSigner:   {{ .IsSigner }},
+		IsWritable: {{ .IsWritable }},
+	})
+	{{- end }}
+	{{- end }}
+	{{- else }}
 
 	keys := []*solana.AccountMeta{
 		{{- range .Accounts }}
@@ -413,26 +3292,370 @@ func New{{ $.Prefix }}{{ $instrName }}Instruction(
 		},
 		{{- end }}
 	}
+	{{- end }}
+	keys = append(keys, remaining...)
 
 	return solana.NewInstruction(
 		{{ $.Prefix }}ProgramID,
 		keys,
 		buf.Bytes(),
-	)
+	){{ if $.Options.CheckAccounts }}, nil{{ end }}
+}
+	{{- end }}
+{{- end }}
+
+// Decode{{ $.Prefix }}{{ $instrName }}Args decodes instruction data for {{ .Name }}, checking the discriminator first.
+{{- $n := discriminatorLen .Discriminator }}
+func Decode{{ $.Prefix }}{{ $instrName }}Args(data []byte) ({{ $.Prefix }}{{ $instrName }}Args, error) {
+	var args {{ $.Prefix }}{{ $instrName }}Args
+	if len(data) < {{ $n }} || {{ discriminatorMismatch $n (print $.Prefix $instrName "Discriminator") }} {
+		actual := data
+		if len(actual) > {{ $n }} {
+			actual = actual[:{{ $n }}]
+		}
+		return args, fmt.Errorf("invalid discriminator for instruction {{ .Name }}: expected %x, got %x", {{ $.Prefix }}{{ $instrName }}Discriminator, actual)
+	}
+	{{- if .Args }}
+	if err := bin.NewBorshDecoder(data[{{ $n }}:]).Decode(&args); err != nil {
+		return args, fmt.Errorf("failed to decode args for instruction {{ .Name }}: %w", err)
+	}
+	{{- end }}
+	return args, nil
+}
+{{- if .Returns }}
+
+// Decode{{ $.Prefix }}{{ $instrName }}Return decodes the return data set by instruction {{ .Name }}.
+func Decode{{ $.Prefix }}{{ $instrName }}Return(data []byte) ({{ mapType .Returns }}, error) {
+	var ret {{ mapType .Returns }}
+	if err := bin.NewBorshDecoder(data).Decode(&ret); err != nil {
+		return ret, fmt.Errorf("failed to decode return data for instruction {{ .Name }}: %w", err)
+	}
+	return ret, nil
+}
+{{- end }}
+{{- if and $.Options.Builders (not $.Options.Minimal) }}
+{{- $associated := and $.SPLHelpers (hasTokenAccounts .Accounts) }}
+
+// {{ $.Prefix }}{{ $instrName }}Builder assembles instruction {{ .Name }} step by step.
+type {{ $.Prefix }}{{ $instrName }}Builder struct {
+	args     {{ $.Prefix }}{{ $instrName }}Args
+	accounts {{ $.Prefix }}{{ $instrName }}Accounts
+	{{- if $associated }}
+	err      error // first error deriving an associated account, reported by Build
+	{{- end }}
+}
+
+// New{{ $.Prefix }}{{ $instrName }}Builder creates a builder for instruction {{ .Name }}, with the accounts the IDL gives a fixed address already set.
+func New{{ $.Prefix }}{{ $instrName }}Builder() *{{ $.Prefix }}{{ $instrName }}Builder {
+	b := &{{ $.Prefix }}{{ $instrName }}Builder{}
+	{{- range .Accounts }}
+	{{- if and .Address (not .Optional) }}
+	b.accounts.{{ .Name | toPascalCase }} = solana.MustPublicKeyFromBase58("{{ .Address }}")
+	{{- end }}
+	{{- end }}
+	return b
+}
+
+// SetArgs sets the instruction arguments.
+func (b *{{ $.Prefix }}{{ $instrName }}Builder) SetArgs(args {{ $.Prefix }}{{ $instrName }}Args) *{{ $.Prefix }}{{ $instrName }}Builder {
+	b.args = args
+	return b
+}
+{{- range .Accounts }}
+
+// Set{{ .Name | toPascalCase }} sets the {{ .Name }} account.
+func (b *{{ $.Prefix }}{{ $instrName }}Builder) Set{{ .Name | toPascalCase }}(key solana.PublicKey) *{{ $.Prefix }}{{ $instrName }}Builder {
+	b.accounts.{{ .Name | toPascalCase }} = {{ if .Optional }}&{{ end }}key
+	return b
+}
+{{- if and $associated (isTokenAccount .Name) }}
+
+// Set{{ .Name | toPascalCase }}Associated sets the {{ .Name }} account to the associated token account of owner for mint.
+func (b *{{ $.Prefix }}{{ $instrName }}Builder) Set{{ .Name | toPascalCase }}Associated(owner, mint solana.PublicKey) *{{ $.Prefix }}{{ $instrName }}Builder {
+	key, _, err := Find{{ $.Prefix }}AssociatedTokenAddress(owner, mint)
+	if err != nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("{{ $instr.Name }}: failed to derive {{ .Name }}: %w", err)
+		}
+		return b
+	}
+	return b.Set{{ .Name | toPascalCase }}(key)
+}
+{{- end }}
+{{- end }}
+
+// Build returns the instruction, or an error if a required account was not set.
+func (b *{{ $.Prefix }}{{ $instrName }}Builder) Build() (solana.Instruction, error) {
+	{{- if $associated }}
+	if b.err != nil {
+		return nil, b.err
+	}
+	{{- end }}
+	{{- if $.Options.CheckAccounts }}
+	return New{{ $.Prefix }}{{ $instrName }}Instruction(b.args, b.accounts)
+}
+	{{- else }}
+	{{- range .Accounts }}
+	{{- if not (or .Optional .Address) }}
+	if b.accounts.{{ .Name | toPascalCase }}.IsZero() {
+		return nil, fmt.Errorf("{{ $instr.Name }}: required account {{ .Name }} is not set")
+	}
+	{{- end }}
+	{{- end }}
+	return New{{ $.Prefix }}{{ $instrName }}Instruction(b.args, b.accounts), nil
+}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if and .IDL.Instructions (not .Options.Minimal) }}
+
+// --- Transaction Builder ---
+
+// {{ .Prefix }}TxBuilder collects instructions to be sent atomically in one transaction.
+type {{ .Prefix }}TxBuilder struct {
+	instructions []solana.Instruction
+	{{- if .Options.CheckAccounts }}
+	err          error // first error from an Add method, reported by Build
+	{{- end }}
+}
+
+// New{{ .Prefix }}TxBuilder creates an empty transaction builder.
+func New{{ .Prefix }}TxBuilder() *{{ .Prefix }}TxBuilder {
+	return &{{ .Prefix }}TxBuilder{}
+}
+
+// Add appends an instruction, which may belong to any program.
+func (b *{{ .Prefix }}TxBuilder) Add(instruction solana.Instruction) *{{ .Prefix }}TxBuilder {
+	b.instructions = append(b.instructions, instruction)
+	return b
+}
+{{- range .IDL.Instructions }}
+{{- $instrName := .Name | toPascalCase }}
+
+// Add{{ $instrName }} appends instruction {{ .Name }}.
+func (b *{{ $.Prefix }}TxBuilder) Add{{ $instrName }}(args {{ $.Prefix }}{{ $instrName }}Args, accounts {{ $.Prefix }}{{ $instrName }}Accounts) *{{ $.Prefix }}TxBuilder {
+	{{- if $.Options.CheckAccounts }}
+	instruction, err := New{{ $.Prefix }}{{ $instrName }}Instruction(args, accounts)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return b
+	}
+	return b.Add(instruction)
+	{{- else }}
+	return b.Add(New{{ $.Prefix }}{{ $instrName }}Instruction(args, accounts))
+	{{- end }}
+}
+{{- end }}
+
+// Build returns a transaction of the added instructions, in order, paid by payer.
+func (b *{{ .Prefix }}TxBuilder) Build(payer solana.PublicKey, blockhash solana.Hash) (*solana.Transaction, error) {
+	{{- if .Options.CheckAccounts }}
+	if b.err != nil {
+		return nil, b.err
+	}
+	{{- end }}
+	return solana.NewTransaction(b.instructions, blockhash, solana.TransactionPayer(payer))
+}
+{{- end }}
+{{- if or .IDL.Instructions .IDL.Accounts }}
+
+// --- Discriminator Registry ---
+
+// {{ .Prefix }}Discriminators maps each 8-byte instruction and account discriminator
+// to "instruction:<name>" or "account:<name>".
+var {{ .Prefix }}Discriminators = map[[8]byte]string{
+	{{- range registryEntries }}
+	{{ registryKey .Var }}: {{ printf "%q" .Name }},
+	{{- end }}
+}
+{{- end }}
+{{- if .IDL.Instructions }}
+
+// Decode{{ .Prefix }}Instruction decodes instruction data into the Args struct of the
+// instruction its discriminator identifies, returning the IDL instruction name.
+func Decode{{ .Prefix }}Instruction(data []byte) (string, interface{}, error) {
+	switch {
+	{{- range .IDL.Instructions }}
+	{{- $n := discriminatorLen .Discriminator }}
+	case len(data) >= {{ $n }} && {{ discriminatorMatch $n (print $.Prefix (.Name | toPascalCase) "Discriminator") }}:
+		args, err := Decode{{ $.Prefix }}{{ .Name | toPascalCase }}Args(data)
+		return "{{ .Name }}", args, err
+	{{- end }}
+	}
+	actual := data
+	if len(actual) > 8 {
+		actual = actual[:8]
+	}
+	return "", nil, fmt.Errorf("unknown instruction discriminator %x", actual)
 }
 {{- end }}
 
+{{- if .ClientName }}
+
 // --- Client ---
 
+// {{ .ClientName }}RPC is the subset of *rpc.Client used by {{ .ClientName }}, so tests can substitute a mock.
+type {{ .ClientName }}RPC interface {
+	{{- if .IDL.Accounts }}
+	GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error)
+	{{- end }}
+	{{- if .IDL.Instructions }}
+	GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error)
+	SendTransaction(ctx context.Context, transaction *solana.Transaction) (solana.Signature, error)
+	SimulateTransaction(ctx context.Context, transaction *solana.Transaction) (*rpc.SimulateTransactionResponse, error)
+	{{- end }}
+}
+
 // {{ .ClientName }} provides easy access to program instructions.
 type {{ .ClientName }} struct {
-	Rpc *rpc.Client
+	Rpc {{ .ClientName }}RPC
 }
 
 // New{{ .ClientName }} creates a new instance of the client.
 func New{{ .ClientName }}(endpoint string) *{{ .ClientName }} {
+	return New{{ .ClientName }}WithRPC(rpc.New(endpoint))
+}
+
+// {{ .ClientName }}Config configures the HTTP transport of New{{ .ClientName }}WithConfig.
+type {{ .ClientName }}Config struct {
+	// Timeout bounds each RPC request; zero means no timeout.
+	Timeout time.Duration
+	// HTTPClient sends the requests; nil uses a new http.Client.
+	HTTPClient *http.Client
+}
+
+// New{{ .ClientName }}WithConfig creates a client whose RPC requests use the configured HTTP client and timeout.
+func New{{ .ClientName }}WithConfig(endpoint string, cfg {{ .ClientName }}Config) *{{ .ClientName }} {
+	httpClient := &http.Client{}
+	if cfg.HTTPClient != nil {
+		c := *cfg.HTTPClient // copied so the timeout does not leak into the caller's client
+		httpClient = &c
+	}
+	if cfg.Timeout > 0 {
+		httpClient.Timeout = cfg.Timeout
+	}
+	rpcClient := jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{HTTPClient: httpClient})
+	return New{{ .ClientName }}WithRPC(rpc.NewWithCustomRPCClient(rpcClient))
+}
+
+// New{{ .ClientName }}WithRPC creates a client using the given RPC implementation.
+func New{{ .ClientName }}WithRPC(rpcClient {{ .ClientName }}RPC) *{{ .ClientName }} {
 	return &{{ .ClientName }}{
-		Rpc: rpc.New(endpoint),
+		Rpc: rpcClient,
+	}
+}
+{{- if .IDL.Accounts }}
+
+// {{ .Prefix }}AccountNotFoundError is returned by the Fetch methods when no account exists at Address.
+type {{ .Prefix }}AccountNotFoundError struct {
+	Address solana.PublicKey
+}
+
+// Error implements the error interface.
+func (e *{{ .Prefix }}AccountNotFoundError) Error() string {
+	return fmt.Sprintf("account %s not found", e.Address)
+}
+
+// Unwrap returns rpc.ErrNotFound.
+func (e *{{ .Prefix }}AccountNotFoundError) Unwrap() error {
+	return rpc.ErrNotFound
+}
+{{- end }}
+{{- range .IDL.Accounts }}
+{{- if or (and .Type .Type.Fields) (hasType .Name) }}
+{{ $accName := .Name | toPascalCase }}
+// Fetch{{ $accName }} fetches and decodes account {{ .Name }} at addr, checking that the program owns it.
+func (c *{{ $.ClientName }}) Fetch{{ $accName }}(ctx context.Context, addr solana.PublicKey) (*{{ $.Prefix }}{{ $accName }}, error) {
+	resp, err := c.Rpc.GetAccountInfo(ctx, addr)
+	if errors.Is(err, rpc.ErrNotFound) || (err == nil && resp.Value == nil) {
+		return nil, &{{ $.Prefix }}AccountNotFoundError{Address: addr}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch account {{ .Name }}: %w", err)
+	}
+	if err := Check{{ $.Prefix }}{{ $accName }}Owner(resp.Value); err != nil {
+		return nil, fmt.Errorf("%s: %w", addr, err)
+	}
+	return Decode{{ $.Prefix }}{{ $accName }}(resp.Value.Data.GetBinary())
+}
+{{- end }}
+{{- end }}
+{{- range .IDL.Instructions }}
+{{ $instrName := .Name | toPascalCase }}
+
+// Build{{ $instrName }}Transaction builds an unsigned transaction for instruction {{ .Name }} using a recent blockhash.
+func (c *{{ $.ClientName }}) Build{{ $instrName }}Transaction(
+	ctx context.Context,
+	args {{ $.Prefix }}{{ $instrName }}Args,
+	accounts {{ $.Prefix }}{{ $instrName }}Accounts,
+	payer solana.PublicKey,
+) (*solana.Transaction, error) {
+	{{- if $.Options.CheckAccounts }}
+	instruction, err := New{{ $.Prefix }}{{ $instrName }}Instruction(args, accounts)
+	if err != nil {
+		return nil, err
+	}
+	{{- else }}
+	instruction := New{{ $.Prefix }}{{ $instrName }}Instruction(args, accounts)
+	{{- end }}
+	recent, err := c.Rpc.GetLatestBlockhash(ctx, rpc.CommitmentFinalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent blockhash: %w", err)
+	}
+	return solana.NewTransaction(
+		[]solana.Instruction{instruction},
+		recent.Value.Blockhash,
+		solana.TransactionPayer(payer),
+	)
+}
+
+// {{ $instrName }} builds, signs and sends instruction {{ .Name }}. The first signer pays the fees.
+func (c *{{ $.ClientName }}) {{ $instrName }}(
+	ctx context.Context,
+	args {{ $.Prefix }}{{ $instrName }}Args,
+	accounts {{ $.Prefix }}{{ $instrName }}Accounts,
+	signers ...solana.PrivateKey,
+) (solana.Signature, error) {
+	if len(signers) == 0 {
+		return solana.Signature{}, errors.New("{{ .Name }}: at least one signer is required, use Build{{ $instrName }}Transaction for unsigned transactions")
 	}
+	tx, err := c.Build{{ $instrName }}Transaction(ctx, args, accounts, signers[0].PublicKey())
+	if err != nil {
+		return solana.Signature{}, err
+	}
+	if _, err := tx.Sign(func(key solana.PublicKey) *solana.PrivateKey {
+		for i := range signers {
+			if signers[i].PublicKey().Equals(key) {
+				return &signers[i]
+			}
+		}
+		return nil
+	}); err != nil {
+		return solana.Signature{}, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	return c.Rpc.SendTransaction(ctx, tx)
+}
+
+// Simulate{{ $instrName }} simulates instruction {{ .Name }} in an unsigned transaction paid by payer,
+// returning the logs and compute units it would consume.
+func (c *{{ $.ClientName }}) Simulate{{ $instrName }}(
+	ctx context.Context,
+	args {{ $.Prefix }}{{ $instrName }}Args,
+	accounts {{ $.Prefix }}{{ $instrName }}Accounts,
+	payer solana.PublicKey,
+) (*rpc.SimulateTransactionResult, error) {
+	tx, err := c.Build{{ $instrName }}Transaction(ctx, args, accounts, payer)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.Rpc.SimulateTransaction(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate {{ .Name }}: %w", err)
+	}
+	return out.Value, nil
 }
+{{- end }}
+{{- end }}
 `
@@ -0,0 +1,1775 @@
+package idlgen
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAddress = "Fg6PaFpoGXkYsidMpWTK6W2BeZ7FEfcYkg476zPFsLnS"
+
+// testIDL returns the JSON of an IDL for the program "test" with the given
+// top-level sections, e.g. `"types": [...]`.
+func testIDL(sections ...string) []byte {
+	doc := `{"name": "test", "address": "` + testAddress + `"`
+	for _, s := range sections {
+		doc += ", " + s
+	}
+	return []byte(doc + "}")
+}
+
+// structIDL returns an IDL defining the struct type name with the given
+// field JSON objects.
+func structIDL(name string, fields ...string) []byte {
+	return testIDL(`"types": [` + structDef(name, fields...) + `]`)
+}
+
+// structDef returns the type definition JSON of the struct name.
+func structDef(name string, fields ...string) string {
+	return `{"name": "` + name + `", "type": {"kind": "struct", "fields": [` + strings.Join(fields, ", ") + `]}}`
+}
+
+// mustGenerate runs generate and fails the test on error.
+func mustGenerate(t testing.TB, data []byte, opts Options) string {
+	t.Helper()
+	code, err := generate(data, opts)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	return string(code)
+}
+
+// runGenerated runs testSrc, the source of a _test.go file in package
+// bindings, against the generated code in a scratch module, as verifyCode
+// does.
+func runGenerated(t *testing.T, code, testSrc string) {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"bindings.go":      packageClause.ReplaceAllString(code, "package bindings"),
+		"bindings_test.go": testSrc,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testModule(t, dir)
+}
+
+// testModule builds and tests the Go files in dir as a scratch module. It
+// skips in short mode and when the solana-go modules are unavailable.
+func testModule(t *testing.T, dir string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("compiles the generated code")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module idlgentest\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runGo(dir, "mod", "tidy"); err != nil {
+		t.Skipf("go mod tidy failed: %v\n%s", err, out)
+	}
+	if out, err := runGo(dir, "test", "."); err != nil {
+		t.Fatalf("generated code tests failed: %v\n%s", err, out)
+	}
+}
+
+// bytesToInts converts b for intSliceToBytesLiteral.
+func bytesToInts(b []byte) []int {
+	ints := make([]int, len(b))
+	for i, v := range b {
+		ints[i] = int(v)
+	}
+	return ints
+}
+
+// assertContains fails the test unless code contains every want.
+func assertContains(t *testing.T, code string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(code, want) {
+			t.Errorf("output does not contain %q:\n%s", want, code)
+		}
+	}
+}
+
+// assertNotContains fails the test if code contains any of unwanted.
+func assertNotContains(t *testing.T, code string, unwanted ...string) {
+	t.Helper()
+	for _, u := range unwanted {
+		if strings.Contains(code, u) {
+			t.Errorf("output contains %q:\n%s", u, code)
+		}
+	}
+}
+
+// fieldType returns the Go type of field in the generated struct typeName.
+func fieldType(t *testing.T, code, typeName, field string) string {
+	t.Helper()
+	decl := regexp.MustCompile(`(?s)\ntype ` + regexp.QuoteMeta(typeName) + ` struct \{\n(.*?)\n\}`).FindStringSubmatch(code)
+	if decl == nil {
+		t.Fatalf("no struct %s in output:\n%s", typeName, code)
+	}
+	m := regexp.MustCompile(`(?m)^\s*` + regexp.QuoteMeta(field) + `\s+(\S.*?)\s*(?:` + "`" + `|//|$)`).FindStringSubmatch(decl[1])
+	if m == nil {
+		t.Fatalf("no field %s in struct %s:\n%s", field, typeName, decl[0])
+	}
+	return m[1]
+}
+
+// --- Output ---
+
+func TestFormatErrorWritesNoOutput(t *testing.T) {
+	dir := t.TempDir()
+	idlPath := filepath.Join(dir, "test.json")
+	if err := os.WriteFile(idlPath, structIDL("Pool", `{"name": "liquidity", "type": "u64"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "test.go")
+	// An unbalanced primitive override breaks the generated struct.
+	err := GenerateWithOptions(idlPath, outPath, Options{PrimitiveMap: map[string]string{"u64": "map[string"}})
+	var fe *FormatError
+	if !errors.As(err, &fe) {
+		t.Fatalf("got error %v, want a *FormatError", err)
+	}
+	if _, err := os.Stat(outPath); !os.IsNotExist(err) {
+		t.Errorf("output file was written (stat error %v)", err)
+	}
+	if fe.DebugPath != outPath+".debug" {
+		t.Errorf("unformatted source saved to %q, want %q", fe.DebugPath, outPath+".debug")
+	}
+}
+
+func TestImportOverrides(t *testing.T) {
+	code := mustGenerate(t, structIDL("Pool", `{"name": "liquidity", "type": "u64"}`), Options{
+		BinImport:    "github.com/fork/binary",
+		SolanaImport: "github.com/fork/solana-go",
+		RPCImport:    "github.com/fork/solana-go/rpc",
+	})
+	assertContains(t, code,
+		"\tbin \"github.com/fork/binary\"\n",
+		"\tsolana \"github.com/fork/solana-go\"\n",
+		"\trpc \"github.com/fork/solana-go/rpc\"\n",
+	)
+	assertNotContains(t, code, "github.com/gagliardetto")
+}
+
+func TestNoClient(t *testing.T) {
+	code := mustGenerate(t, structIDL("Pool", `{"name": "liquidity", "type": "u64"}`), Options{ClientName: NoClient})
+	assertNotContains(t, code, "solana-go/rpc\"", "Client struct")
+	assertContains(t, code, "type TestPool struct")
+}
+
+func TestMinimal(t *testing.T) {
+	data := testIDL(
+		`"instructions": [{"name": "deposit", "accounts": [{"name": "vault", "writable": true}], "args": [{"name": "amount", "type": "u64"}]}]`,
+		`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`,
+	)
+	code := mustGenerate(t, data, Options{Minimal: true})
+	assertNotContains(t, code, "solana-go/rpc\"", "func NewTestDepositInstruction(")
+	assertContains(t, code, "type TestDepositArgs struct", "type TestDepositAccounts struct", "type TestVault struct")
+}
+
+func TestTemplateCachePerPrefix(t *testing.T) {
+	data := testIDL(`"types": [` + structDef("Foo", `{"name": "x", "type": "u8"}`) + `, ` + structDef("Bar", `{"name": "foo", "type": {"defined": "Foo"}}`) + `]`)
+	for _, prefix := range []string{"Alpha", "Beta"} {
+		code := mustGenerate(t, data, Options{Prefix: prefix})
+		if got := fieldType(t, code, prefix+"Bar", "Foo"); got != prefix+"Foo" {
+			t.Errorf("prefix %s: Foo has type %s, want %sFoo", prefix, got, prefix)
+		}
+	}
+}
+
+// BenchmarkGenerate measures repeated generations, which share the parsed
+// template.
+func BenchmarkGenerate(b *testing.B) {
+	data := testIDL(
+		`"instructions": [{"name": "deposit", "accounts": [{"name": "vault", "writable": true}], "args": [{"name": "amount", "type": "u64"}]}]`,
+		`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`,
+		`"types": [`+structDef("Pool", `{"name": "liquidity", "type": "u64"}`, `{"name": "authority", "type": "pubkey"}`)+`]`,
+	)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := generate(data, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestGenerateFromURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/idl/pool.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(structIDL("Pool", `{"name": "liquidity", "type": "u64"}`))
+	}))
+	defer srv.Close()
+
+	outPath := filepath.Join(t.TempDir(), "pool.go")
+	if err := GenerateWithOptions(srv.URL+"/idl/pool.json", outPath, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, readFile(t, outPath), "type TestPool struct")
+
+	err := GenerateWithOptions(srv.URL+"/idl/missing.json", outPath, Options{})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("got error %v, want the 404 status", err)
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	dir := t.TempDir()
+	idlPath, outPath := filepath.Join(dir, "test.json"), filepath.Join(dir, "test.go")
+	if err := os.WriteFile(idlPath, structIDL("Pool", `{"name": "liquidity", "type": "u64"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Incremental: true}
+	if err := GenerateWithOptions(idlPath, outPath, opts); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(outPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	modTime := func() time.Time {
+		info, err := os.Stat(outPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.ModTime()
+	}
+
+	if err := GenerateWithOptions(idlPath, outPath, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !modTime().Equal(old) {
+		t.Error("second run with identical input rewrote the output")
+	}
+	opts.Force = true
+	if err := GenerateWithOptions(idlPath, outPath, opts); err != nil {
+		t.Fatal(err)
+	}
+	if modTime().Equal(old) {
+		t.Error("forced run did not rewrite the output")
+	}
+}
+
+func TestHeaderIDLHash(t *testing.T) {
+	data := structIDL("Pool", `{"name": "liquidity", "type": "u64"}`)
+	code := mustGenerate(t, data, Options{})
+	m := regexp.MustCompile(`(?m)^// IDL sha256: ([0-9a-f]{64})$`).FindStringSubmatch(code)
+	if m == nil {
+		t.Fatalf("no IDL sha256 line in header:\n%s", code)
+	}
+	if want := fmt.Sprintf("%x", sha256.Sum256(data)); m[1] != want {
+		t.Errorf("header records %s, want %s", m[1], want)
+	}
+}
+
+func TestHeaderBanner(t *testing.T) {
+	code := mustGenerate(t, structIDL("Pool"), Options{Header: "Copyright 2024 Example Corp.\n\n// SPDX-License-Identifier: MIT\n"})
+	want := "// Copyright 2024 Example Corp.\n//\n// SPDX-License-Identifier: MIT\n\n// Code generated by idlgen"
+	if !strings.HasPrefix(code, want) {
+		t.Errorf("output does not start with the banner %q:\n%s", want, code)
+	}
+}
+
+// --- Naming ---
+
+func TestToPascalCase(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"initializeMint", "InitializeMint"},
+		{"setAuthority", "SetAuthority"},
+		{"reallocBuffer2", "ReallocBuffer2"},
+		{"initialize_mint", "InitializeMint"},
+		{"initialize_account_3", "InitializeAccount3"},
+		{"Initialize", "Initialize"},
+	}
+	for _, tt := range tests {
+		if got := toPascalCase(tt.in); got != tt.want {
+			t.Errorf("toPascalCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTagName(t *testing.T) {
+	tests := []struct {
+		name    string
+		tagCase string
+		want    string
+	}{
+		{"lastUpdateSlot", TagCaseRaw, "lastUpdateSlot"},
+		{"last_update_slot", TagCaseRaw, "last_update_slot"},
+		{"lastUpdateSlot", TagCaseSnake, "last_update_slot"},
+		{"last_update_slot", TagCaseSnake, "last_update_slot"},
+		{"HTTPEndpoint", TagCaseSnake, "http_endpoint"},
+		{"last_update_slot", TagCaseCamel, "lastUpdateSlot"},
+		{"LastUpdateSlot", TagCaseCamel, "lastUpdateSlot"},
+		{"type", TagCaseCamel, "type"},
+	}
+	for _, tt := range tests {
+		if got := tagName(tt.name, tt.tagCase); got != tt.want {
+			t.Errorf("tagName(%q, %q) = %q, want %q", tt.name, tt.tagCase, got, tt.want)
+		}
+	}
+}
+
+// --- IDL Parsing ---
+
+func TestAccountFlagSpellings(t *testing.T) {
+	tests := []struct {
+		json string
+		want IdlAccount
+	}{
+		{`{"name": "vault", "writable": true, "signer": true, "optional": true}`, IdlAccount{Name: "vault", IsWritable: true, IsSigner: true, Optional: true}},
+		{`{"name": "vault", "isMut": true, "isSigner": true, "isOptional": true}`, IdlAccount{Name: "vault", IsWritable: true, IsSigner: true, Optional: true}},
+		{`{"name": "vault", "isMut": false, "signer": true}`, IdlAccount{Name: "vault", IsSigner: true}},
+	}
+	for _, tt := range tests {
+		var got IdlAccount
+		if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s decoded to %+v, want %+v", tt.json, got, tt.want)
+		}
+	}
+}
+
+// --- Type Mapping ---
+
+func TestMapTypePrimitives(t *testing.T) {
+	tests := []struct {
+		primitive string
+		want      string
+	}{
+		{"i8", "int8"},
+		{"u8", "uint8"},
+		{"i16", "int16"},
+		{"u16", "uint16"},
+		{"i32", "int32"},
+		{"u32", "uint32"},
+		{"i64", "int64"},
+		{"u64", "uint64"},
+		{"bool", "bool"},
+		{"string", "string"},
+		{"bytes", "[]byte"},
+		{"pubkey", "solana.PublicKey"},
+		{"publicKey", "solana.PublicKey"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.primitive, func(t *testing.T) {
+			code := mustGenerate(t, structIDL("Sample", `{"name": "value", "type": "`+tt.primitive+`"}`), Options{})
+			if got := fieldType(t, code, "TestSample", "Value"); got != tt.want {
+				t.Errorf("%s maps to %s, want %s", tt.primitive, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMapTypeFloats(t *testing.T) {
+	code := mustGenerate(t, structIDL("Price", `{"name": "ratio", "type": "f32"}`, `{"name": "value", "type": "f64"}`), Options{})
+	if got := fieldType(t, code, "TestPrice", "Ratio"); got != "float32" {
+		t.Errorf("f32 maps to %s, want float32", got)
+	}
+	if got := fieldType(t, code, "TestPrice", "Value"); got != "float64" {
+		t.Errorf("f64 maps to %s, want float64", got)
+	}
+}
+
+func TestMapTypeCOption(t *testing.T) {
+	data := testIDL(`"types": [` +
+		structDef("Inner", `{"name": "x", "type": "u8"}`) + `, ` +
+		structDef("Outer", `{"name": "inner", "type": {"coption": {"defined": "Inner"}}}`) + `]`)
+	code := mustGenerate(t, data, Options{})
+	if got := fieldType(t, code, "TestOuter", "Inner"); got != "*TestInner" {
+		t.Errorf("coption<Inner> maps to %s, want *TestInner", got)
+	}
+	assertContains(t, code, "`bin:\"inner coption\"`")
+}
+
+func TestMapTypeNestedDefined(t *testing.T) {
+	data := testIDL(`"types": [` +
+		structDef("Foo", `{"name": "x", "type": "u8"}`) + `, ` +
+		structDef("Holder", `{"name": "foos", "type": {"option": {"vec": {"defined": {"name": "Foo"}}}}}`) + `]`)
+	code := mustGenerate(t, data, Options{})
+	if got := fieldType(t, code, "TestHolder", "Foos"); got != "*[]TestFoo" {
+		t.Errorf("option<vec<Foo>> maps to %s, want *[]TestFoo", got)
+	}
+}
+
+func TestArraySize(t *testing.T) {
+	tests := []struct {
+		size interface{}
+		want int
+		ok   bool
+	}{
+		{float64(32), 32, true},
+		{json.Number("8"), 8, true},
+		{"16", 16, true},
+		{map[string]interface{}{"kind": "const", "value": "4"}, 4, true},
+		{"MAX_LEN", 0, false},
+		{float64(1.5), 0, false},
+		{map[string]interface{}{"defined": "MAX_LEN"}, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := arraySize(tt.size)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("arraySize(%#v) = %d, %v, want %d, %v", tt.size, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMapTypeNamedArraySize(t *testing.T) {
+	data := testIDL(`"constants": [{"name": "MAX_LEN", "type": "usize", "value": "16"}]`, `"types": [`+structDef("Buf",
+		`{"name": "named", "type": {"array": ["u8", "MAX_LEN"]}}`,
+		`{"name": "defined", "type": {"array": ["u8", {"defined": "MAX_LEN"}]}}`,
+		`{"name": "unknown", "type": {"array": ["u8", "OTHER"]}}`,
+	)+`]`)
+	code := mustGenerate(t, data, Options{})
+	for field, want := range map[string]string{"Named": "[TestMAXLEN]uint8", "Defined": "[TestMAXLEN]uint8", "Unknown": "[]uint8"} {
+		if got := fieldType(t, code, "TestBuf", field); got != want {
+			t.Errorf("%s has type %s, want %s", field, got, want)
+		}
+	}
+	assertContains(t, code, "// size: OTHER")
+}
+
+func TestTypeMap(t *testing.T) {
+	data := structIDL("Quote",
+		`{"name": "price", "type": {"defined": "Price"}}`,
+		`{"name": "stamp", "type": {"option": {"defined": "Stamp"}}}`,
+	)
+	code := mustGenerate(t, data, Options{TypeMap: map[string]string{
+		"Price": "github.com/org/oracle.Price",
+		"Stamp": "time.Time",
+	}})
+	if got := fieldType(t, code, "TestQuote", "Price"); got != "oracle.Price" {
+		t.Errorf("Price has type %s, want oracle.Price", got)
+	}
+	if got := fieldType(t, code, "TestQuote", "Stamp"); got != "*time.Time" {
+		t.Errorf("Stamp has type %s, want *time.Time", got)
+	}
+	assertContains(t, code, "\t\"github.com/org/oracle\"\n", "\t\"time\"\n")
+	assertNotContains(t, code, "type TestPrice ")
+}
+
+func TestPrimitiveMapOverridesPubkey(t *testing.T) {
+	var primitiveMap map[string]string
+	if err := json.Unmarshal([]byte(`{"pubkey": "github.com/org/types.Pubkey", "f16": "github.com/org/types.Half"}`), &primitiveMap); err != nil {
+		t.Fatal(err)
+	}
+	data := structIDL("Pool",
+		`{"name": "authority", "type": "pubkey"}`,
+		`{"name": "signers", "type": {"vec": "pubkey"}}`,
+		`{"name": "ratio", "type": "f16"}`,
+	)
+	code := mustGenerate(t, data, Options{PrimitiveMap: primitiveMap, Strict: true})
+	for field, want := range map[string]string{
+		"Authority": "types.Pubkey",
+		"Signers":   "[]types.Pubkey",
+		"Ratio":     "types.Half",
+	} {
+		if got := fieldType(t, code, "TestPool", field); got != want {
+			t.Errorf("%s has type %s, want %s", field, got, want)
+		}
+	}
+	assertContains(t, code, "\t\"github.com/org/types\"\n")
+}
+
+func TestU128TypeOverride(t *testing.T) {
+	data := structIDL("Pool", `{"name": "liquidity", "type": "u128"}`, `{"name": "delta", "type": "i128"}`)
+	code := mustGenerate(t, data, Options{U128Type: "math/big.Int"})
+	if got := fieldType(t, code, "TestPool", "Liquidity"); got != "big.Int" {
+		t.Errorf("u128 maps to %s, want big.Int", got)
+	}
+	if got := fieldType(t, code, "TestPool", "Delta"); got != "bin.Int128" {
+		t.Errorf("i128 maps to %s, want bin.Int128", got)
+	}
+	assertContains(t, code, "\t\"math/big\"\n")
+}
+
+func TestMapTypeNestedPrimitives(t *testing.T) {
+	data := structIDL("Keys",
+		`{"name": "signers", "type": {"array": ["pubkey", 32]}}`,
+		`{"name": "admins", "type": {"array": ["publicKey", 4]}}`,
+		`{"name": "paused", "type": {"option": "bool"}}`,
+		`{"name": "deltas", "type": {"vec": {"array": ["i8", 2]}}}`,
+	)
+	code := mustGenerate(t, data, Options{})
+	for field, want := range map[string]string{
+		"Signers": "[32]solana.PublicKey",
+		"Admins":  "[4]solana.PublicKey",
+		"Paused":  "*bool",
+		"Deltas":  "[][2]int8",
+	} {
+		if got := fieldType(t, code, "TestKeys", field); got != want {
+			t.Errorf("%s has type %s, want %s", field, got, want)
+		}
+	}
+}
+
+func TestNestedOptionRoundTrip(t *testing.T) {
+	data := structIDL("Limits",
+		`{"name": "cap", "type": {"option": {"option": "u64"}}}`,
+		`{"name": "groups", "type": {"vec": {"vec": "pubkey"}}}`,
+		`{"name": "flags", "type": {"vec": {"option": "u8"}}}`,
+		`{"name": "tail", "type": "u8"}`,
+	)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, Assertions: true})
+	if got := fieldType(t, code, "TestLimits", "Cap"); got != "**uint64" {
+		t.Errorf("Cap has type %s, want **uint64", got)
+	}
+	assertContains(t, code, "_ bin.EncoderDecoder = (*TestLimits)(nil)")
+	runGenerated(t, code, `package bindings
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestNestedRoundTrip(t *testing.T) {
+	value := uint64(5)
+	present := &value
+	var absent *uint64
+	for _, tc := range []struct {
+		name   string
+		cap    **uint64
+		prefix []byte
+	}{
+		{"none", nil, []byte{0}},
+		{"some none", &absent, []byte{1, 0}},
+		{"some some", &present, []byte{1, 1, 5, 0, 0, 0, 0, 0, 0, 0}},
+	} {
+		want := TestLimits{Cap: tc.cap, Tail: 9}
+		data, err := want.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantData := append(tc.prefix, 0, 0, 0, 0, 0, 0, 0, 0, 9); !bytes.Equal(data, wantData) {
+			t.Errorf("%s: encoding is %v, want %v", tc.name, data, wantData)
+		}
+		var got TestLimits
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got.Cap, want.Cap) || got.Tail != want.Tail {
+			t.Errorf("%s: round trip gave %+v, want %+v", tc.name, got, want)
+		}
+	}
+}
+
+func TestNestedSlicesRoundTrip(t *testing.T) {
+	flag := uint8(3)
+	want := TestLimits{
+		Groups: [][]solana.PublicKey{
+			{solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()},
+			{solana.NewWallet().PublicKey()},
+		},
+		Flags: []*uint8{nil, &flag},
+		Tail:  1,
+	}
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wantLen := 1 + 4 + 2*4 + 3*32 + 4 + 1 + 2 + 1; len(data) != wantLen {
+		t.Errorf("encoding is %d bytes, want %d", len(data), wantLen)
+	}
+	var got TestLimits
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip gave %+v, want %+v", got, want)
+	}
+}
+`)
+}
+
+func TestFieldDocs(t *testing.T) {
+	data := testIDL(
+		`"instructions": [{"name": "deposit", "docs": ["Deposits into the pool."], "accounts": [], "args": []}]`,
+		`"types": [`+structDef("Pool", `{"name": "liquidity", "docs": ["Total liquidity in lamports."], "type": "u64"}`)+`]`,
+	)
+	code := mustGenerate(t, data, Options{})
+	assertContains(t, code,
+		"\t// Total liquidity in lamports.\n\tLiquidity uint64",
+		"// Deposits into the pool.\nfunc NewTestDepositInstruction(",
+	)
+}
+
+func TestMapTypeMaps(t *testing.T) {
+	data := testIDL(`"types": [` +
+		structDef("Order", `{"name": "id", "type": "u64"}`) + `, ` +
+		structDef("Book",
+			`{"name": "balances", "type": {"hashMap": ["pubkey", "u64"]}}`,
+			`{"name": "orders", "type": {"btreeMap": ["string", {"vec": {"defined": "Order"}}]}}`,
+		) + `]`)
+	code := mustGenerate(t, data, Options{})
+	if got := fieldType(t, code, "TestBook", "Balances"); got != "map[solana.PublicKey]uint64" {
+		t.Errorf("hashMap<pubkey, u64> maps to %s, want map[solana.PublicKey]uint64", got)
+	}
+	if got := fieldType(t, code, "TestBook", "Orders"); got != "map[string][]TestOrder" {
+		t.Errorf("btreeMap<string, vec<Order>> maps to %s, want map[string][]TestOrder", got)
+	}
+}
+
+func TestMapTypeWideIntegers(t *testing.T) {
+	data := structIDL("Wide", `{"name": "big", "type": "u256"}`, `{"name": "signed", "type": "i256"}`)
+	code := mustGenerate(t, data, Options{})
+	for _, field := range []string{"Big", "Signed"} {
+		if got := fieldType(t, code, "TestWide", field); got != "[32]byte" {
+			t.Errorf("%s has type %s, want [32]byte", field, got)
+		}
+	}
+	code = mustGenerate(t, data, Options{U256Type: "github.com/holiman/uint256.Int"})
+	if got := fieldType(t, code, "TestWide", "Big"); got != "uint256.Int" {
+		t.Errorf("u256 with an override maps to %s, want uint256.Int", got)
+	}
+	assertContains(t, code, "\t\"github.com/holiman/uint256\"\n")
+}
+
+func TestStrictUnknownPrimitive(t *testing.T) {
+	data := structIDL("Pool", `{"name": "weird", "type": "u96"}`)
+	_, err := generate(data, Options{Strict: true})
+	if err == nil || !strings.Contains(err.Error(), "u96") {
+		t.Errorf("got error %v, want the unknown primitive u96 reported", err)
+	}
+	code := mustGenerate(t, data, Options{})
+	if got := fieldType(t, code, "TestPool", "Weird"); got != "interface{}" {
+		t.Errorf("unknown primitive maps to %s without -strict, want interface{}", got)
+	}
+}
+
+func TestArraySizeForms(t *testing.T) {
+	tests := []struct {
+		size string
+		want string // Go type of the field, "" for any
+	}{
+		{`4`, "[4]uint8"},
+		{`"4"`, "[4]uint8"},
+		{`{"kind": "const", "value": "4"}`, "[4]uint8"},
+		{`{"kind": "const", "value": 4}`, "[4]uint8"},
+		{`"MAX_LEN"`, "[TestMAXLEN]uint8"},
+		{`{"defined": "MAX_LEN"}`, "[TestMAXLEN]uint8"},
+		{`{"defined": {"name": "MAX_LEN"}}`, "[TestMAXLEN]uint8"},
+		{`{"value": "MAX_LEN"}`, ""},
+		{`{"generic": "N"}`, ""},
+		{`-1`, ""},
+		{`null`, ""},
+		{`[]`, ""},
+	}
+	for _, tt := range tests {
+		data := testIDL(`"constants": [{"name": "MAX_LEN", "type": "usize", "value": "4"}]`,
+			`"types": [`+structDef("Buf", `{"name": "data", "type": {"array": ["u8", `+tt.size+`]}}`)+`]`)
+		// Every form must generate or fail cleanly, without panicking.
+		code, err := generate(data, Options{})
+		if err != nil {
+			if tt.want != "" {
+				t.Errorf("size %s: %v", tt.size, err)
+			}
+			continue
+		}
+		if got := fieldType(t, string(code), "TestBuf", "Data"); tt.want != "" && got != tt.want {
+			t.Errorf("size %s maps to %s, want %s", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestUndefinedAsBytes(t *testing.T) {
+	data := structIDL("Pool", `{"name": "amount", "type": "u64"}`, `{"name": "padding", "type": {"defined": "Reserved"}}`)
+	if _, err := generate(data, Options{}); err == nil || !strings.Contains(err.Error(), "Reserved") {
+		t.Errorf("got error %v, want the dangling Reserved reference reported", err)
+	}
+	code := mustGenerate(t, data, Options{UndefinedAsBytes: true})
+	if got := fieldType(t, code, "TestPool", "Padding"); got != "[]byte" {
+		t.Errorf("dangling reference maps to %s, want []byte", got)
+	}
+}
+
+// --- Constants ---
+
+func TestConstants(t *testing.T) {
+	data := testIDL(`"constants": [
+		{"name": "MAX_FEE", "type": "u64", "value": "10_000"},
+		{"name": "SEED", "type": "string", "value": "\"vault\""},
+		{"name": "TAG", "type": "bytes", "value": "[1, 2]"}
+	]`)
+	code := mustGenerate(t, data, Options{})
+	assertContains(t, code,
+		"const TestMAXFEE uint64 = 10000\n",
+		"const TestSEED string = \"vault\"\n",
+		"var TestTAG = []byte{0x01, 0x02}\n",
+	)
+}
+
+func TestEnumString(t *testing.T) {
+	data := testIDL(`"types": [{"name": "Side", "type": {"kind": "enum", "variants": [{"name": "bid"}, {"name": "ask"}]}}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	assertContains(t, code, "TestSideBid TestSide = iota\n\tTestSideAsk\n")
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestSideString(t *testing.T) {
+	for i, want := range []string{"bid", "ask", "Unknown(2)"} {
+		if got := TestSide(i).String(); got != want {
+			t.Errorf("TestSide(%d).String() = %q, want %q", i, got, want)
+		}
+	}
+}
+`)
+}
+
+func TestTupleVariant(t *testing.T) {
+	data := testIDL(`"types": [{"name": "Action", "type": {"kind": "enum", "variants": [
+		{"name": "none"},
+		{"name": "transfer", "fields": ["u64", "pubkey"]}
+	]}}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	if got := fieldType(t, code, "TestActionTransferVariant", "Field0"); got != "uint64" {
+		t.Errorf("Field0 has type %s, want uint64", got)
+	}
+	if got := fieldType(t, code, "TestActionTransferVariant", "Field1"); got != "solana.PublicKey" {
+		t.Errorf("Field1 has type %s, want solana.PublicKey", got)
+	}
+	runGenerated(t, code, `package bindings
+
+import (
+	"bytes"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestTupleEncoding(t *testing.T) {
+	to := solana.NewWallet().PublicKey()
+	action := TestAction{Kind: TestActionTransfer, Transfer: &TestActionTransferVariant{Field0: 42, Field1: to}}
+	var buf bytes.Buffer
+	if err := bin.NewBorshEncoder(&buf).Encode(action); err != nil {
+		t.Fatal(err)
+	}
+	want := append([]byte{1, 42, 0, 0, 0, 0, 0, 0, 0}, to[:]...)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("encoded %x, want %x", buf.Bytes(), want)
+	}
+}
+`)
+}
+
+func TestEqualBytesField(t *testing.T) {
+	data := structIDL("Blob", `{"name": "id", "type": "u64"}`, `{"name": "data", "type": "bytes"}`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, Equal: true})
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestBlobEqual(t *testing.T) {
+	a := &TestBlob{Id: 1, Data: []byte{1, 2, 3}}
+	if !a.Equal(&TestBlob{Id: 1, Data: []byte{1, 2, 3}}) {
+		t.Error("equal blobs compare unequal")
+	}
+	if a.Equal(&TestBlob{Id: 1, Data: []byte{1, 2, 4}}) {
+		t.Error("blobs with different data compare equal")
+	}
+	if a.Equal(nil) {
+		t.Error("blob equals nil")
+	}
+}
+`)
+}
+
+func TestConstructors(t *testing.T) {
+	data := structIDL("Pool",
+		`{"name": "authority", "type": "pubkey"}`,
+		`{"name": "fee_bps", "type": "u16"}`,
+		`{"name": "tags", "type": {"vec": "string"}}`,
+	)
+	code := mustGenerate(t, data, Options{Constructors: true})
+	assertContains(t, code, "func NewTestPool(\n\tauthority solana.PublicKey,\n\tfeeBps uint16,\n\ttags []string,\n) TestPool {")
+
+	// The doc reads the same whatever article the prefix would take.
+	code = mustGenerate(t, data, Options{Constructors: true, Prefix: "Idl"})
+	assertContains(t, code, "// NewIdlPool builds a value of type IdlPool from its fields in IDL order.\n")
+	assertNotContains(t, code, "a IdlPool")
+}
+
+func TestAssertions(t *testing.T) {
+	data := testIDL(`"types": [
+		{"name": "Side", "type": {"kind": "enum", "variants": [{"name": "bid"}, {"name": "ask"}]}},
+		` + structDef("Pool", `{"name": "x", "type": "u8"}`) + `
+	]`)
+	guards := []string{
+		"_ encoding.BinaryMarshaler   = (*TestPool)(nil)",
+		"_ encoding.BinaryUnmarshaler = (*TestPool)(nil)",
+		"_ fmt.Stringer     = (*TestSide)(nil)",
+		"_ json.Marshaler   = (*TestSide)(nil)",
+		"_ json.Unmarshaler = (*TestSide)(nil)",
+	}
+	assertContains(t, mustGenerate(t, data, Options{Assertions: true, EnumJSON: true}), guards...)
+	// Without -enum-json there are no JSON methods to assert.
+	code := mustGenerate(t, data, Options{Assertions: true})
+	assertContains(t, code, "_ fmt.Stringer = (*TestSide)(nil)")
+	assertNotContains(t, code, "json.Marshaler")
+	assertNotContains(t, mustGenerate(t, data, Options{EnumJSON: true}), "encoding.BinaryMarshaler", "fmt.Stringer")
+}
+
+// --- Accounts ---
+
+func TestAccountInlineFields(t *testing.T) {
+	data := testIDL(`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [
+		{"name": "owner", "type": "pubkey"},
+		{"name": "amount", "type": "u64"}
+	]}}]`)
+	code := mustGenerate(t, data, Options{})
+	if got := fieldType(t, code, "TestVault", "Owner"); got != "solana.PublicKey" {
+		t.Errorf("Owner has type %s, want solana.PublicKey", got)
+	}
+	if got := fieldType(t, code, "TestVault", "Amount"); got != "uint64" {
+		t.Errorf("Amount has type %s, want uint64", got)
+	}
+	assertContains(t, code, "func DecodeTestVault(data []byte) (*TestVault, error)")
+}
+
+func TestAccountBinaryRoundTrip(t *testing.T) {
+	data := testIDL(`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [
+		{"name": "owner", "type": "pubkey"},
+		{"name": "amount", "type": "u64"},
+		{"name": "label", "type": "string"},
+		{"name": "limit", "type": {"option": "u32"}}
+	]}}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	runGenerated(t, code, `package bindings
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestVaultRoundTrip(t *testing.T) {
+	limit := uint32(7)
+	want := TestVault{Owner: solana.NewWallet().PublicKey(), Amount: 42, Label: "main", Limit: &limit}
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, TestVaultDiscriminator) {
+		t.Errorf("encoding %x does not start with the discriminator", data)
+	}
+	var got TestVault
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip gave %+v, want %+v", got, want)
+	}
+}
+`)
+}
+
+// vaultIDL declares the account Vault with discriminator 1..8.
+var vaultIDL = testIDL(`"accounts": [{"name": "Vault", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "type": {"kind": "struct", "fields": [
+	{"name": "owner", "type": "pubkey"},
+	{"name": "amount", "type": "u64"}
+]}}]`)
+
+func TestAccountFilters(t *testing.T) {
+	code := mustGenerate(t, vaultIDL, Options{})
+	runGenerated(t, code, `package bindings
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	filters := TestVaultFilters()
+	if len(filters) != 1 || filters[0].Memcmp == nil {
+		t.Fatalf("got filters %+v, want one memcmp filter", filters)
+	}
+	if filters[0].Memcmp.Offset != 0 {
+		t.Errorf("filter offset %d, want 0", filters[0].Memcmp.Offset)
+	}
+	if !bytes.Equal(filters[0].Memcmp.Bytes, []byte{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("filter bytes %x, want the discriminator", filters[0].Memcmp.Bytes)
+	}
+}
+`)
+}
+
+func TestFetchAccount(t *testing.T) {
+	code := mustGenerate(t, vaultIDL, Options{})
+	runGenerated(t, code, `package bindings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type mockRPC struct {
+	accounts map[solana.PublicKey]*rpc.Account
+}
+
+func (m mockRPC) GetAccountInfo(ctx context.Context, account solana.PublicKey) (*rpc.GetAccountInfoResult, error) {
+	return &rpc.GetAccountInfoResult{Value: m.accounts[account]}, nil
+}
+
+func TestFetch(t *testing.T) {
+	want := TestVault{Owner: solana.NewWallet().PublicKey(), Amount: 42}
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, missing := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	client := NewTestClientWithRPC(mockRPC{accounts: map[solana.PublicKey]*rpc.Account{
+		addr: {Owner: TestProgramID, Data: rpc.DataBytesOrJSONFromBytes(data)},
+	}})
+
+	got, err := client.FetchVault(context.Background(), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != want {
+		t.Errorf("fetched %+v, want %+v", *got, want)
+	}
+	if _, err := client.FetchVault(context.Background(), missing); err == nil {
+		t.Error("fetched a missing account")
+	}
+}
+`)
+}
+
+func TestAccountSize(t *testing.T) {
+	data := testIDL(`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [
+		{"name": "owner", "type": "pubkey"},
+		{"name": "amount", "type": "u64"},
+		{"name": "active", "type": "bool"},
+		{"name": "tag", "type": {"array": ["u8", 4]}},
+		{"name": "limit", "type": {"option": "u32"}}
+	]}}]`)
+	code := mustGenerate(t, data, Options{})
+	// discriminator 8 + pubkey 32 + u64 8 + bool 1 + [u8; 4] 4 + option<u32> 1+4
+	assertContains(t, code, "const TestVaultSize = 58\n")
+}
+
+func TestValidateEnumRange(t *testing.T) {
+	data := testIDL(
+		`"accounts": [{"name": "Order", "type": {"kind": "struct", "fields": [{"name": "side", "type": {"defined": "Side"}}]}}]`,
+		`"types": [{"name": "Side", "type": {"kind": "enum", "variants": [{"name": "bid"}, {"name": "ask"}]}}]`,
+	)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, Validate: true})
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	if err := (TestOrder{Side: TestSideAsk}).Validate(); err != nil {
+		t.Errorf("valid order: %v", err)
+	}
+	if err := (TestOrder{Side: 5}).Validate(); err == nil {
+		t.Error("order with side 5 passed validation")
+	}
+}
+`)
+}
+
+func TestClientTimeout(t *testing.T) {
+	code := mustGenerate(t, vaultIDL, Options{})
+	runGenerated(t, code, `package bindings
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	client := NewTestClientWithConfig(srv.URL, TestClientConfig{Timeout: 50 * time.Millisecond})
+	start := time.Now()
+	if _, err := client.FetchVault(context.Background(), solana.NewWallet().PublicKey()); err == nil {
+		t.Fatal("fetch from a stalled server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("fetch took %v, want the 50ms timeout applied", elapsed)
+	}
+}
+`)
+}
+
+func TestAccountDiscriminatorCase(t *testing.T) {
+	data := testIDL(`"accounts": [{"name": "PoolState", "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`)
+	for _, tt := range []struct {
+		casing   string
+		preimage string
+	}{
+		{AccountCasePascal, "account:PoolState"},
+		{AccountCaseSnake, "account:pool_state"},
+	} {
+		code := mustGenerate(t, data, Options{AccountNameCase: tt.casing})
+		sum := sha256.Sum256([]byte(tt.preimage))
+		assertContains(t, code, "var TestPoolStateDiscriminator = []byte{"+intSliceToBytesLiteral(bytesToInts(sum[:8]))+"}")
+	}
+}
+
+// --- Events ---
+
+func TestEventTwoFields(t *testing.T) {
+	data := testIDL(`"events": [{"name": "Deposited", "fields": [
+		{"name": "user", "type": "pubkey", "index": false},
+		{"name": "amount", "type": "u64", "index": false}
+	]}]`)
+	code := mustGenerate(t, data, Options{})
+	if got := fieldType(t, code, "TestDeposited", "User"); got != "solana.PublicKey" {
+		t.Errorf("User has type %s, want solana.PublicKey", got)
+	}
+	if got := fieldType(t, code, "TestDeposited", "Amount"); got != "uint64" {
+		t.Errorf("Amount has type %s, want uint64", got)
+	}
+	sum := sha256.Sum256([]byte("event:Deposited"))
+	assertContains(t, code,
+		"var TestEventDepositedDiscriminator = []byte{"+intSliceToBytesLiteral(bytesToInts(sum[:8]))+"}",
+		"func DecodeTestDeposited(data []byte) (*TestDeposited, error)",
+	)
+}
+
+// --- Instructions ---
+
+func TestPDAHelper(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "deposit", "accounts": [
+		{"name": "owner", "signer": true},
+		{"name": "vault", "writable": true, "pda": {"seeds": [
+			{"kind": "const", "value": [118, 97, 117, 108, 116]},
+			{"kind": "account", "path": "owner"}
+		]}}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{})
+	assertContains(t, code,
+		"func FindTestDepositVaultAddress(owner solana.PublicKey) (solana.PublicKey, uint8, error) {",
+		"[]byte{0x76, 0x61, 0x75, 0x6c, 0x74},\n\t\towner.Bytes(),\n\t}, TestProgramID)",
+	)
+}
+
+func TestOptionalAccounts(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "close", "accounts": [
+		{"name": "owner", "signer": true},
+		{"name": "referrer", "optional": true}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	assertContains(t, code, "Referrer *solana.PublicKey\n", "if accounts.Referrer != nil {")
+	runGenerated(t, code, `package bindings
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestOptionalAccountKeys(t *testing.T) {
+	owner, referrer := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	without := NewTestCloseInstruction(TestCloseArgs{}, TestCloseAccounts{Owner: owner})
+	if n := len(without.Accounts()); n != 1 {
+		t.Errorf("without referrer: %d keys, want 1", n)
+	}
+	with := NewTestCloseInstruction(TestCloseArgs{}, TestCloseAccounts{Owner: owner, Referrer: &referrer})
+	if n := len(with.Accounts()); n != 2 {
+		t.Errorf("with referrer: %d keys, want 2", n)
+	}
+}
+`)
+}
+
+func TestNestedAccountGroups(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "swap", "accounts": [
+		{"name": "user", "signer": true},
+		{"name": "pool", "accounts": [
+			{"name": "authority"},
+			{"name": "vaults", "accounts": [{"name": "base", "writable": true}]}
+		]}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{})
+	for _, field := range []string{"User", "PoolAuthority", "PoolVaultsBase"} {
+		if got := fieldType(t, code, "TestSwapAccounts", field); got != "solana.PublicKey" {
+			t.Errorf("%s has type %s, want solana.PublicKey", field, got)
+		}
+	}
+	assertContains(t, code, "PublicKey:  accounts.PoolVaultsBase,\n\t\t\tIsSigner:   false,\n\t\t\tIsWritable: true,")
+}
+
+func TestInstructionReturns(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "get_price", "accounts": [], "args": [], "returns": "u64"}]`)
+	code := mustGenerate(t, data, Options{})
+	assertContains(t, code, "func DecodeTestGetPriceReturn(data []byte) (uint64, error) {")
+}
+
+func TestDiscriminatorArray(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "initialize", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [], "args": []}]`)
+	code := mustGenerate(t, data, Options{DiscriminatorArray: true})
+	assertContains(t, code, "var TestInitializeDiscriminator = [8]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}")
+	code = mustGenerate(t, data, Options{})
+	assertContains(t, code, "var TestInitializeDiscriminator = []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}")
+}
+
+func TestCheckAccounts(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "close", "accounts": [
+		{"name": "owner", "signer": true},
+		{"name": "vault", "writable": true},
+		{"name": "system_program", "address": "11111111111111111111111111111111"}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, CheckAccounts: true})
+	runGenerated(t, code, `package bindings
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestRequiredAccount(t *testing.T) {
+	_, err := NewTestCloseInstruction(TestCloseArgs{}, TestCloseAccounts{Owner: solana.NewWallet().PublicKey()})
+	if err == nil || err.Error() != "close: required account vault is not set" {
+		t.Errorf("got error %v, want the unset vault reported", err)
+	}
+	accounts := TestCloseAccounts{Owner: solana.NewWallet().PublicKey(), Vault: solana.NewWallet().PublicKey(), SystemProgram: solana.SystemProgramID}
+	if _, err := NewTestCloseInstruction(TestCloseArgs{}, accounts); err != nil {
+		t.Errorf("all accounts set: %v", err)
+	}
+	if _, err := NewTestTxBuilder().AddClose(TestCloseArgs{}, accounts).Build(accounts.Owner, solana.Hash{1}); err != nil {
+		t.Errorf("transaction with the system program: %v", err)
+	}
+}
+`)
+}
+
+func TestShortDiscriminator(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "transfer", "discriminator": [7], "accounts": [], "args": [{"name": "amount", "type": "u64"}]}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	assertContains(t, code, "var TestTransferDiscriminator = []byte{0x07}", "data[:1]")
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestDecodeOneByteDiscriminator(t *testing.T) {
+	args, err := DecodeTestTransferArgs([]byte{7, 42, 0, 0, 0, 0, 0, 0, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.Amount != 42 {
+		t.Errorf("decoded amount %d, want 42", args.Amount)
+	}
+	if _, err := DecodeTestTransferArgs([]byte{8, 42, 0, 0, 0, 0, 0, 0, 0}); err == nil {
+		t.Error("decoded data with the wrong discriminator")
+	}
+}
+`)
+}
+
+func TestAccountIndexConstants(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "swap", "accounts": [
+		{"name": "user", "signer": true},
+		{"name": "pool", "accounts": [{"name": "authority"}, {"name": "vault", "writable": true}]},
+		{"name": "token_program"}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{})
+	for i, name := range []string{"User", "PoolAuthority", "PoolVault", "TokenProgram"} {
+		if !regexp.MustCompile(`\tTestSwapAccountIndex` + name + ` += ` + strconv.Itoa(i) + `\n`).MatchString(code) {
+			t.Errorf("no TestSwapAccountIndex%s = %d in output:\n%s", name, i, code)
+		}
+	}
+}
+
+func TestDiscriminatorRegistry(t *testing.T) {
+	data := testIDL(
+		`"instructions": [{"name": "deposit", "discriminator": [9, 9, 9, 9, 9, 9, 9, 9], "accounts": [], "args": []}]`,
+		`"accounts": [{"name": "Vault", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`,
+	)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestRegistry(t *testing.T) {
+	for disc, want := range map[[8]byte]string{
+		{1, 2, 3, 4, 5, 6, 7, 8}: "account:Vault",
+		{9, 9, 9, 9, 9, 9, 9, 9}: "instruction:deposit",
+	} {
+		if got := TestDiscriminators[disc]; got != want {
+			t.Errorf("TestDiscriminators[%x] = %q, want %q", disc, got, want)
+		}
+	}
+}
+`)
+}
+
+func TestU32LEDiscriminators(t *testing.T) {
+	if got, want := u32leDiscriminator(258), []int{2, 1, 0, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("u32leDiscriminator(258) = %v, want %v", got, want)
+	}
+	data := testIDL(`"instructions": [
+		{"name": "initialize", "accounts": [], "args": []},
+		{"name": "deposit", "accounts": [], "args": []},
+		{"name": "withdraw", "discriminator": [7], "accounts": [], "args": []}
+	]`)
+	code := mustGenerate(t, data, Options{DiscriminatorMode: DiscriminatorModeU32LE})
+	assertContains(t, code,
+		"var TestInitializeDiscriminator = []byte{0x00, 0x00, 0x00, 0x00}",
+		"var TestDepositDiscriminator = []byte{0x01, 0x00, 0x00, 0x00}",
+		"var TestWithdrawDiscriminator = []byte{0x07, 0x00, 0x00, 0x00}",
+	)
+}
+
+func TestEmptyArgsAndAccounts(t *testing.T) {
+	data := testIDL(`"instructions": [
+		{"name": "ping", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [{"name": "user", "signer": true}], "args": []},
+		{"name": "log", "discriminator": [2, 2, 3, 4, 5, 6, 7, 8], "accounts": [], "args": [{"name": "x", "type": "u8"}]}
+	]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	assertContains(t, code, "type TestPingArgs struct{}\n", "type TestLogAccounts struct{}\n")
+	runGenerated(t, code, `package bindings
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestNoArgs(t *testing.T) {
+	ix := NewTestPingInstruction(TestPingArgs{}, TestPingAccounts{User: solana.NewWallet().PublicKey()})
+	data, err := ix.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, TestPingDiscriminator) {
+		t.Errorf("data %x, want only the discriminator", data)
+	}
+}
+
+func TestNoAccounts(t *testing.T) {
+	ix := NewTestLogInstruction(TestLogArgs{X: 3}, TestLogAccounts{})
+	if n := len(ix.Accounts()); n != 0 {
+		t.Errorf("%d keys, want 0", n)
+	}
+	data, err := ix.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append(append([]byte{}, TestLogDiscriminator...), 3); !bytes.Equal(data, want) {
+		t.Errorf("data %x, want %x", data, want)
+	}
+}
+`)
+}
+
+func TestInstructionDispatcher(t *testing.T) {
+	data := testIDL(`"instructions": [
+		{"name": "deposit", "discriminator": [1, 1, 1, 1, 1, 1, 1, 1], "accounts": [], "args": [{"name": "amount", "type": "u64"}]},
+		{"name": "rename", "discriminator": [2, 2, 2, 2, 2, 2, 2, 2], "accounts": [], "args": [{"name": "label", "type": "string"}]}
+	]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestDispatch(t *testing.T) {
+	deposit, err := NewTestDepositInstruction(TestDepositArgs{Amount: 5}, TestDepositAccounts{}).Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rename, err := NewTestRenameInstruction(TestRenameArgs{Label: "vault"}, TestRenameAccounts{}).Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, args, err := DecodeTestInstruction(deposit)
+	if err != nil || name != "deposit" || args != (TestDepositArgs{Amount: 5}) {
+		t.Errorf("deposit decoded to %q, %+v, %v", name, args, err)
+	}
+	name, args, err = DecodeTestInstruction(rename)
+	if err != nil || name != "rename" || args != (TestRenameArgs{Label: "vault"}) {
+		t.Errorf("rename decoded to %q, %+v, %v", name, args, err)
+	}
+	if _, _, err := DecodeTestInstruction([]byte{3, 3, 3, 3, 3, 3, 3, 3}); err == nil {
+		t.Error("decoded an unknown discriminator")
+	}
+}
+`)
+}
+
+func TestSimulateInstruction(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "ping", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [{"name": "user", "signer": true}], "args": []}]`)
+	code := mustGenerate(t, data, Options{})
+	runGenerated(t, code, `package bindings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+type mockRPC struct {
+	simulated *solana.Transaction
+}
+
+func (m *mockRPC) GetLatestBlockhash(ctx context.Context, commitment rpc.CommitmentType) (*rpc.GetLatestBlockhashResult, error) {
+	return &rpc.GetLatestBlockhashResult{Value: &rpc.LatestBlockhashResult{Blockhash: solana.Hash{1}}}, nil
+}
+
+func (m *mockRPC) SendTransaction(ctx context.Context, transaction *solana.Transaction) (solana.Signature, error) {
+	panic("simulation must not send")
+}
+
+func (m *mockRPC) SimulateTransaction(ctx context.Context, transaction *solana.Transaction) (*rpc.SimulateTransactionResponse, error) {
+	m.simulated = transaction
+	return &rpc.SimulateTransactionResponse{Value: &rpc.SimulateTransactionResult{Logs: []string{"Program log: pong"}}}, nil
+}
+
+func TestSimulate(t *testing.T) {
+	mock := &mockRPC{}
+	payer := solana.NewWallet().PublicKey()
+	result, err := NewTestClientWithRPC(mock).SimulatePing(context.Background(), TestPingArgs{}, TestPingAccounts{User: payer}, payer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Logs) != 1 || result.Logs[0] != "Program log: pong" {
+		t.Errorf("got logs %q", result.Logs)
+	}
+	if mock.simulated == nil || len(mock.simulated.Message.Instructions) != 1 {
+		t.Fatalf("simulated %v, want a transaction with one instruction", mock.simulated)
+	}
+	if got := mock.simulated.Message.AccountKeys[0]; got != payer {
+		t.Errorf("fee payer %s, want %s", got, payer)
+	}
+}
+`)
+}
+
+func TestTxBuilder(t *testing.T) {
+	data := testIDL(`"instructions": [
+		{"name": "ping", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [{"name": "user", "signer": true}], "args": []},
+		{"name": "log", "discriminator": [2, 2, 3, 4, 5, 6, 7, 8], "accounts": [], "args": [{"name": "x", "type": "u8"}]}
+	]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	runGenerated(t, code, `package bindings
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestBuild(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	tx, err := NewTestTxBuilder().
+		AddPing(TestPingArgs{}, TestPingAccounts{User: payer}).
+		AddLog(TestLogArgs{X: 1}, TestLogAccounts{}).
+		Build(payer, solana.Hash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(tx.Message.Instructions); n != 2 {
+		t.Errorf("transaction has %d instructions, want 2", n)
+	}
+}
+`)
+}
+
+func TestComputeHints(t *testing.T) {
+	data := testIDL(`"instructions": [
+		{"name": "deposit", "docs": ["Deposits into the pool.", "Compute units: 45,000"], "accounts": [], "args": []},
+		{"name": "withdraw", "docs": ["Withdraws from the pool."], "accounts": [], "args": []}
+	]`)
+	code := mustGenerate(t, data, Options{ComputeHints: true})
+	assertContains(t, code, "const TestDepositComputeUnits = 45000\n")
+	assertNotContains(t, code, "TestWithdrawComputeUnits")
+
+	code = mustGenerate(t, data, Options{})
+	assertNotContains(t, code, "TestDepositComputeUnits")
+}
+
+func TestAnchorInstructionDiscriminator(t *testing.T) {
+	// sha256("global:initialize")[:8], as computed by Anchor.
+	initialize := []int{0xaf, 0xaf, 0x6d, 0x1f, 0x0d, 0x98, 0x9b, 0xed}
+	poolSum := sha256.Sum256([]byte("global:initialize_pool"))
+	data := testIDL(`"instructions": [
+		{"name": "initialize", "accounts": [], "args": []},
+		{"name": "initializePool", "accounts": [], "args": []}
+	]`)
+	code := mustGenerate(t, data, Options{})
+	assertContains(t, code,
+		"var TestInitializeDiscriminator = []byte{"+intSliceToBytesLiteral(initialize)+"}",
+		"var TestInitializePoolDiscriminator = []byte{"+intSliceToBytesLiteral(bytesToInts(poolSum[:8]))+"}",
+	)
+
+	rawSum := sha256.Sum256([]byte("ix:initializePool"))
+	code = mustGenerate(t, data, Options{InstrNamespace: "ix", InstrNameCase: InstructionCaseRaw})
+	assertContains(t, code, "var TestInitializePoolDiscriminator = []byte{"+intSliceToBytesLiteral(bytesToInts(rawSum[:8]))+"}")
+}
+
+func TestSPLHelpers(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "deposit", "accounts": [
+		{"name": "user", "signer": true},
+		{"name": "user_token_account", "writable": true},
+		{"name": "token_program", "address": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA"}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, SPLHelpers: true, Builders: true})
+	assertContains(t, code,
+		"func FindTestAssociatedTokenAddress(owner, mint solana.PublicKey) (solana.PublicKey, uint8, error)",
+		"func (b *TestDepositBuilder) SetUserTokenAccountAssociated(owner, mint solana.PublicKey) *TestDepositBuilder",
+	)
+	runGenerated(t, code, `package bindings
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestAssociated(t *testing.T) {
+	owner, mint := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	want, _, err := solana.FindAssociatedTokenAddress(owner, mint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ix, err := NewTestDepositBuilder().
+		SetUser(owner).
+		SetUserTokenAccountAssociated(owner, mint).
+		SetTokenProgram(solana.TokenProgramID).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ix.Accounts()[1].PublicKey; !got.Equals(want) {
+		t.Errorf("user_token_account is %s, want %s", got, want)
+	}
+}
+`)
+
+	assertNotContains(t, mustGenerate(t, data, Options{}), "AssociatedTokenAddress")
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	var instrs, accounts []string
+	for i, name := range []string{"swap", "deposit", "withdraw", "close", "admin_set", "initialize"} {
+		instrs = append(instrs, fmt.Sprintf(`{"name": %q, "discriminator": [%d, 1, 1, 1, 1, 1, 1, 1], "accounts": [], "args": []}`, name, i))
+		accounts = append(accounts, fmt.Sprintf(`{"name": %q, "discriminator": [%d, 2, 2, 2, 2, 2, 2, 2], "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}`, toPascalCase(name)+"State", i))
+	}
+	data := testIDL(
+		`"instructions": [`+strings.Join(instrs, ", ")+`]`,
+		`"accounts": [`+strings.Join(accounts, ", ")+`]`,
+	)
+	first := mustGenerate(t, data, Options{})
+	assertContains(t, first, "var TestDiscriminators = map[[8]byte]string{")
+	for i := 0; i < 10; i++ {
+		if code := mustGenerate(t, data, Options{}); code != first {
+			t.Fatalf("run %d differs from the first", i+2)
+		}
+	}
+}
+
+func TestArgReferencesAccount(t *testing.T) {
+	data := testIDL(
+		`"instructions": [{"name": "restore", "accounts": [], "args": [{"name": "snapshot", "type": {"defined": {"name": "Vault"}}}]}]`,
+		`"accounts": [{"name": "Vault", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`,
+	)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, Strict: true})
+	if got := fieldType(t, code, "TestRestoreArgs", "Snapshot"); got != "TestVault" {
+		t.Errorf("Snapshot has type %s, want TestVault", got)
+	}
+	runGenerated(t, code, `package bindings
+
+import (
+	"bytes"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+func TestSnapshotArg(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := bin.NewBorshEncoder(buf).Encode(TestRestoreArgs{Snapshot: TestVault{Amount: 7}}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{7, 0, 0, 0, 0, 0, 0, 0}; !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("args encode to %v, want %v", buf.Bytes(), want)
+	}
+}
+`)
+}
+
+func TestOptionHelpers(t *testing.T) {
+	data := structIDL("Pool",
+		`{"name": "fee_bps", "type": {"option": "u16"}}`,
+		`{"name": "liquidity", "type": "u64"}`,
+	)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, OptionHelpers: true})
+	assertNotContains(t, code, "HasLiquidity")
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestHas(t *testing.T) {
+	var pool TestPool
+	if pool.HasFeeBps() {
+		t.Error("HasFeeBps is true for a nil option")
+	}
+	fee := uint16(30)
+	pool.FeeBps = &fee
+	if !pool.HasFeeBps() {
+		t.Error("HasFeeBps is false for a set option")
+	}
+}
+`)
+
+	assertNotContains(t, mustGenerate(t, data, Options{}), "HasFeeBps")
+}
+
+func TestComplexEnumRoundTrip(t *testing.T) {
+	data := testIDL(`"types": [{"name": "Order", "type": {"kind": "enum", "variants": [
+		{"name": "cancel"},
+		{"name": "limit", "fields": [{"name": "price", "type": "u64"}, {"name": "size", "type": "u32"}]}
+	]}}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	runGenerated(t, code, `package bindings
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	bin "github.com/gagliardetto/binary"
+)
+
+func TestVariantRoundTrip(t *testing.T) {
+	for _, want := range []TestOrder{
+		{Kind: TestOrderCancel},
+		{Kind: TestOrderLimit, Limit: &TestOrderLimitVariant{Price: 100, Size: 3}},
+	} {
+		var buf bytes.Buffer
+		if err := bin.NewBorshEncoder(&buf).Encode(want); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Bytes()[0] != byte(want.Kind) {
+			t.Errorf("%v encodes variant index %d", want.Kind, buf.Bytes()[0])
+		}
+		var got TestOrder
+		if err := bin.NewBorshDecoder(buf.Bytes()).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip gave %+v, want %+v", got, want)
+		}
+	}
+}
+`)
+}
+
+func TestCheckAccountOwner(t *testing.T) {
+	code := mustGenerate(t, vaultIDL, Options{})
+	runGenerated(t, code, `package bindings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc"
+)
+
+func TestOwner(t *testing.T) {
+	if err := CheckTestVaultOwner(&rpc.Account{Owner: TestProgramID}); err != nil {
+		t.Errorf("program-owned account rejected: %v", err)
+	}
+	err := CheckTestVaultOwner(&rpc.Account{Owner: solana.SystemProgramID})
+	if err == nil || !strings.Contains(err.Error(), "owned by "+solana.SystemProgramID.String()) {
+		t.Errorf("got error %v for a system-owned account, want an owner mismatch", err)
+	}
+	if err := CheckTestVaultOwner(nil); err == nil {
+		t.Error("nil account accepted")
+	}
+}
+`)
+}
+
+func TestDescribe(t *testing.T) {
+	data := testIDL(`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [
+		{"name": "owner", "type": "pubkey"},
+		{"name": "amount", "type": "u64"},
+		{"name": "memo", "type": "bytes"}
+	]}}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, Describe: true})
+	runGenerated(t, code, `package bindings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestDescribeVault(t *testing.T) {
+	owner := solana.NewWallet().PublicKey()
+	got := TestVault{Owner: owner, Amount: 42, Memo: []byte{0xca, 0xfe}}.Describe()
+	for _, want := range []string{"Owner: " + owner.String(), "Amount: 42", "Memo: cafe"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Describe() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+`)
+}
+
+func TestMetadataConstants(t *testing.T) {
+	data := testIDL(`"metadata": {"name": "test", "version": "0.3.1", "spec": "0.1.0", "repository": "https://github.com/org/test"}`)
+	code := mustGenerate(t, data, Options{})
+	assertContains(t, code,
+		"const TestVersion = \"0.3.1\"\n",
+		"const TestSpec = \"0.1.0\"\n",
+		"const TestRepository = \"https://github.com/org/test\"\n",
+	)
+
+	legacy := mustGenerate(t, testIDL(`"version": "0.2.0"`), Options{})
+	assertContains(t, legacy, "const TestVersion = \"0.2.0\"\n")
+	assertNotContains(t, legacy, "const TestSpec", "const TestRepository")
+
+	bare := mustGenerate(t, structIDL("Pool", `{"name": "liquidity", "type": "u64"}`), Options{})
+	assertNotContains(t, bare, "const TestVersion", "const TestSpec", "const TestRepository", `= ""`)
+}
+
+func TestMetadataName(t *testing.T) {
+	// Anchor 0.30 IDLs name the program in the metadata block only.
+	data := []byte(`{"address": "` + testAddress + `", "metadata": {"name": "token_vault", "version": "0.1.0"}, "instructions": []}`)
+	code := mustGenerate(t, data, Options{ProgramName: "vault_idl"})
+	assertContains(t, code, "// Program: token_vault\n", "var TokenVaultProgramID = ", "const TokenVaultVersion = ")
+	assertNotContains(t, code, "VaultIdl")
+}
+
+func TestRemainingAccounts(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "swap", "accounts": [
+		{"name": "user", "signer": true},
+		{"name": "pool", "writable": true}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	runGenerated(t, code, `package bindings
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestRemaining(t *testing.T) {
+	user, pool := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	hop1, hop2 := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	ix := NewTestSwapInstruction(TestSwapArgs{}, TestSwapAccounts{User: user, Pool: pool},
+		solana.Meta(hop1).WRITE(),
+		solana.Meta(hop2),
+	)
+	keys := ix.Accounts()
+	if len(keys) != 4 {
+		t.Fatalf("instruction has %d accounts, want 4", len(keys))
+	}
+	if !keys[2].PublicKey.Equals(hop1) || !keys[2].IsWritable || !keys[3].PublicKey.Equals(hop2) {
+		t.Errorf("remaining accounts are %v, %v; want %s (writable), %s", keys[2], keys[3], hop1, hop2)
+	}
+}
+`)
+}
+
+func TestSkippedField(t *testing.T) {
+	data := structIDL("Pool",
+		`{"name": "liquidity", "type": "u64"}`,
+		`{"name": "price_cache", "type": "u64", "skip": true}`,
+		`{"name": "tier", "type": "u8", "attrs": ["skip", "default = 3"]}`,
+	)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	assertContains(t, code,
+		"\t// Not part of the Borsh encoding.\n\tPriceCache uint64 `bin:\"-\"`",
+		"\t// Not part of the Borsh encoding.\n\t// Defaults to 3.\n\tTier uint8 `bin:\"-\"`",
+		"Liquidity uint64 `bin:\"liquidity\"`",
+	)
+	runGenerated(t, code, `package bindings
+
+import "testing"
+
+func TestSkipped(t *testing.T) {
+	data, err := TestPool{Liquidity: 1, PriceCache: 99, Tier: 2}.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 8 {
+		t.Errorf("encoding is %d bytes, want the 8 of liquidity only", len(data))
+	}
+}
+`)
+}
+
+func TestBuilderSystemProgram(t *testing.T) {
+	data := testIDL(`"instructions": [{"name": "initialize", "accounts": [
+		{"name": "payer", "writable": true, "signer": true},
+		{"name": "system_program", "address": "11111111111111111111111111111111"}
+	], "args": []}]`)
+	code := mustGenerate(t, data, Options{ClientName: NoClient, Builders: true})
+	runGenerated(t, code, `package bindings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestBuildSystemProgram(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	ix, err := NewTestInitializeBuilder().SetPayer(payer).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ix.Accounts()[1].PublicKey; !got.Equals(solana.SystemProgramID) {
+		t.Errorf("system_program is %s, want %s", got, solana.SystemProgramID)
+	}
+	if _, err := NewTestInitializeBuilder().Build(); err == nil || !strings.Contains(err.Error(), "required account payer is not set") {
+		t.Errorf("got error %v without a payer, want the missing payer", err)
+	}
+}
+`)
+}
@@ -0,0 +1,70 @@
+package idlgen
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+)
+
+// --- Import Pruning ---
+
+// pruneImports drops the imports src does not reference, so packages imported
+// on a condition the template got wrong never leave the output uncompilable.
+// Blank and dot imports, and imports whose package name cannot be guessed
+// from their path, are kept.
+func pruneImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	for _, decl := range file.Decls {
+		selectorNames(decl, used)
+	}
+
+	// Whole lines of unused import specs, removed back to front.
+	var drop [][2]int
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name := importName(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		if name == "_" || name == "." || !token.IsIdentifier(name) || used[name] {
+			continue
+		}
+		start := fset.Position(spec.Pos())
+		end := fset.Position(spec.End()).Offset
+		lineStart := start.Offset - (start.Column - 1)
+		for end < len(src) && src[end] != '\n' {
+			end++
+		}
+		drop = append(drop, [2]int{lineStart, end + 1})
+	}
+	if len(drop) == 0 {
+		return src, nil
+	}
+	sort.Slice(drop, func(i, j int) bool { return drop[i][0] > drop[j][0] })
+	out := append([]byte(nil), src...)
+	for _, r := range drop {
+		out = append(out[:r[0]], out[r[1]:]...)
+	}
+	return format.Source(out)
+}
+
+// selectorNames adds the package-like identifiers that qualify selectors in
+// node, such as "fmt" in fmt.Errorf, to used.
+func selectorNames(node ast.Node, used map[string]bool) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				used[x.Name] = true
+			}
+		}
+		return true
+	})
+}
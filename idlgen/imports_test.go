@@ -0,0 +1,33 @@
+package idlgen
+
+import "testing"
+
+func TestPruneImports(t *testing.T) {
+	src := []byte(`package bindings
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go/rpc"
+	_ "embed"
+)
+
+func f() error { return fmt.Errorf("x") }
+`)
+	out, err := pruneImports(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertNotContains(t, string(out), "solana-go/rpc")
+	assertContains(t, string(out), "\"fmt\"", "_ \"embed\"")
+}
+
+func TestNoClientDropsRPCImport(t *testing.T) {
+	data := testIDL(
+		`"instructions": [{"name": "deposit", "accounts": [{"name": "vault", "writable": true}], "args": [{"name": "amount", "type": "u64"}]}]`,
+		`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`,
+	)
+	code := mustGenerate(t, data, Options{ClientName: NoClient})
+	assertNotContains(t, code, "rpc\"", "rpc.")
+	runGenerated(t, code, "package bindings\n")
+}
@@ -0,0 +1,99 @@
+package idlgen
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// --- Byte Array Inlining ---
+
+// inlineByteArrays replaces every reference to a byte-array alias, a struct
+// type whose only field is an array of u8 (e.g. a 32-byte Hash), with the
+// array itself, so it maps to [N]byte at its use sites. Both encode the same
+// in Borsh. The alias definitions are dropped unless an account or event
+// shares their name. IDLs without such aliases are returned unchanged.
+func inlineByteArrays(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	kept := make(map[string]bool) // names accounts and events resolve through the types section
+	for _, section := range []string{"accounts", "events"} {
+		entries, _ := doc[section].([]interface{})
+		for _, e := range entries {
+			entry, _ := e.(map[string]interface{})
+			name, _ := entry["name"].(string)
+			kept[name] = true
+		}
+	}
+
+	types, _ := doc["types"].([]interface{})
+	aliases := make(map[string]interface{})
+	var remaining []interface{}
+	for _, t := range types {
+		def, _ := t.(map[string]interface{})
+		name, _ := def["name"].(string)
+		if array := byteArrayAlias(def); array != nil && !kept[name] {
+			aliases[name] = array
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+	if len(aliases) == 0 {
+		return data, nil
+	}
+	doc["types"] = remaining
+	return json.Marshal(inlineAliases(doc, aliases))
+}
+
+// byteArrayAlias returns the {"array": ["u8", N]} type of the single field of
+// def, or nil when def is not a byte-array alias.
+func byteArrayAlias(def map[string]interface{}) interface{} {
+	if params, _ := def["generics"].([]interface{}); len(params) > 0 {
+		return nil
+	}
+	ty, _ := def["type"].(map[string]interface{})
+	fields, _ := ty["fields"].([]interface{})
+	if ty["kind"] != "struct" || len(fields) != 1 {
+		return nil
+	}
+	field := fields[0]
+	if named, ok := field.(map[string]interface{}); ok {
+		if inner, ok := named["type"]; ok {
+			field = inner
+		}
+	}
+	if array := innerType(field).Array; array == nil || !isByte(innerType(array[0])) {
+		return nil
+	}
+	return field
+}
+
+// inlineAliases copies node, replacing defined references to aliases with
+// their array type.
+func inlineAliases(node interface{}, aliases map[string]interface{}) interface{} {
+	switch n := node.(type) {
+	case []interface{}:
+		out := make([]interface{}, len(n))
+		for i, v := range n {
+			out[i] = inlineAliases(v, aliases)
+		}
+		return out
+	case map[string]interface{}:
+		if _, ok := n["defined"]; ok && len(n) == 1 {
+			if array, ok := aliases[derefName(innerType(n).Defined)]; ok {
+				return array
+			}
+		}
+		out := make(map[string]interface{}, len(n))
+		for k, v := range n {
+			out[k] = inlineAliases(v, aliases)
+		}
+		return out
+	default:
+		return node
+	}
+}
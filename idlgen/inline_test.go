@@ -0,0 +1,24 @@
+package idlgen
+
+import "testing"
+
+func TestInlineByteArrays(t *testing.T) {
+	data := testIDL(
+		`"accounts": [{"name": "Checkpoint", "type": {"kind": "struct", "fields": [{"name": "root", "type": {"defined": {"name": "Hash"}}}]}}]`,
+		`"types": [`+structDef("Hash", `{"name": "bytes", "type": {"array": ["u8", 32]}}`)+`, `+
+			structDef("Proof", `{"name": "leaves", "type": {"vec": {"defined": "Hash"}}}`)+`]`,
+	)
+	code := mustGenerate(t, data, Options{InlineByteArrays: true})
+	if got := fieldType(t, code, "TestCheckpoint", "Root"); got != "[32]uint8" {
+		t.Errorf("Root has type %s, want [32]uint8", got)
+	}
+	if got := fieldType(t, code, "TestProof", "Leaves"); got != "[][32]uint8" {
+		t.Errorf("Leaves has type %s, want [][32]uint8", got)
+	}
+	assertNotContains(t, code, "type TestHash ")
+
+	code = mustGenerate(t, data, Options{})
+	if got := fieldType(t, code, "TestCheckpoint", "Root"); got != "TestHash" {
+		t.Errorf("without inlining, Root has type %s, want TestHash", got)
+	}
+}
@@ -0,0 +1,99 @@
+package idlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// --- IDL Merging ---
+
+// mergedSections are the IDL sections whose entries are combined by name
+// when several IDLs are merged.
+var mergedSections = []string{"instructions", "accounts", "types", "events", "errors", "constants"}
+
+// readMerged reads the IDLs at paths and merges them into one IDL document,
+// for programs split across a core IDL and extensions. Entries of the same
+// section and name must be identical in every IDL that declares them, and
+// error codes and program addresses must not conflict.
+func readMerged(paths []string) ([]byte, error) {
+	merged := make(map[string]interface{})
+	seen := make(map[string]map[string]interface{}) // section -> name -> entry
+	origin := make(map[string]string)               // section + name -> path
+	errorCodes := make(map[string]string)           // error code -> section + name
+	address := ""
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		data, err := readInput(p)
+		if err != nil {
+			return nil, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("%s: failed to parse IDL: %v", p, err)
+		}
+
+		docAddress, _ := doc["address"].(string)
+		if meta, ok := doc["metadata"].(map[string]interface{}); ok && docAddress == "" {
+			docAddress, _ = meta["address"].(string)
+		}
+		if docAddress != "" {
+			if address != "" && address != docAddress {
+				return nil, fmt.Errorf("%s: program address %s conflicts with %s", p, docAddress, address)
+			}
+			address = docAddress
+		}
+
+		for key, value := range doc {
+			if _, ok := merged[key]; !ok && !isMergedSection(key) {
+				merged[key] = value
+			}
+		}
+		for _, section := range mergedSections {
+			entries, _ := doc[section].([]interface{})
+			if seen[section] == nil {
+				seen[section] = make(map[string]interface{})
+			}
+			for _, entry := range entries {
+				fields, _ := entry.(map[string]interface{})
+				name, _ := fields["name"].(string)
+				key := section + " " + name
+				if prev, ok := seen[section][name]; ok {
+					if !reflect.DeepEqual(prev, entry) {
+						return nil, fmt.Errorf("conflicting definitions of %s %q in %s and %s", strings.TrimSuffix(section, "s"), name, origin[key], p)
+					}
+					continue
+				}
+				if section == "errors" {
+					code := fmt.Sprint(fields["code"])
+					if other, ok := errorCodes[code]; ok {
+						return nil, fmt.Errorf("error code %s of %q in %s is already used by %s", code, name, p, other)
+					}
+					errorCodes[code] = fmt.Sprintf("%q in %s", name, p)
+				}
+				seen[section][name] = entry
+				origin[key] = p
+				list, _ := merged[section].([]interface{})
+				merged[section] = append(list, entry)
+			}
+		}
+	}
+	if address != "" {
+		merged["address"] = address
+	}
+	return json.Marshal(merged)
+}
+
+// isMergedSection reports whether key names a section merged entry by entry.
+func isMergedSection(key string) bool {
+	for _, section := range mergedSections {
+		if key == section {
+			return true
+		}
+	}
+	return false
+}
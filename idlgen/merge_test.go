@@ -0,0 +1,39 @@
+package idlgen
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeIDLs(t *testing.T) {
+	dir := t.TempDir()
+	writeIDLs(t, dir, map[string][]byte{
+		"core": testIDL(
+			`"instructions": [{"name": "deposit", "accounts": [], "args": [{"name": "amount", "type": "u64"}]}]`,
+			`"types": [`+structDef("Pool", `{"name": "liquidity", "type": "u64"}`)+`]`,
+		),
+		"extensions": testIDL(
+			`"instructions": [{"name": "withdraw", "accounts": [], "args": [{"name": "pool", "type": {"defined": "Pool"}}]}]`,
+			`"types": [`+structDef("Pool", `{"name": "liquidity", "type": "u64"}`)+`]`,
+		),
+	})
+	core, extensions := filepath.Join(dir, "core.json"), filepath.Join(dir, "extensions.json")
+	outPath := filepath.Join(dir, "vault.go")
+	if err := GenerateWithOptions(core+","+extensions, outPath, Options{Prefix: "Vault"}); err != nil {
+		t.Fatal(err)
+	}
+	code := readFile(t, outPath)
+	assertContains(t, code, "type VaultDepositArgs struct", "type VaultWithdrawArgs struct")
+	if n := strings.Count(code, "type VaultPool struct"); n != 1 {
+		t.Errorf("Pool is declared %d times, want once", n)
+	}
+
+	writeIDLs(t, dir, map[string][]byte{
+		"conflict": structIDL("Pool", `{"name": "liquidity", "type": "u128"}`),
+	})
+	err := GenerateWithOptions(core+","+filepath.Join(dir, "conflict.json"), outPath, Options{})
+	if err == nil || !strings.Contains(err.Error(), `conflicting definitions of type "Pool"`) {
+		t.Errorf("got error %v, want a conflicting Pool definition", err)
+	}
+}
@@ -0,0 +1,259 @@
+package idlgen
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// --- On-chain IDL ---
+
+// DefaultRPCEndpoint is the RPC endpoint used to fetch on-chain IDLs when none is given.
+const DefaultRPCEndpoint = "https://api.mainnet-beta.solana.com"
+
+// anchorIDLSeed is the seed Anchor uses to derive the IDL account from the
+// program's base PDA.
+const anchorIDLSeed = "anchor:idl"
+
+// idlAccountHeader is the size of the IDL account fields before the
+// compressed IDL: 8-byte discriminator, 32-byte authority and u32 length.
+const idlAccountHeader = 8 + 32 + 4
+
+// GenerateFromProgram fetches the IDL Anchor published on-chain for programID
+// through the RPC endpoint and writes the Go bindings to outPath.
+func GenerateFromProgram(endpoint, programID, outPath string, opts Options) error {
+	if programID == "" || outPath == "" {
+		return fmt.Errorf("program and out paths are required")
+	}
+	if endpoint == "" {
+		endpoint = DefaultRPCEndpoint
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	data, err := FetchOnChainIDL(ctx, endpoint, programID)
+	if err != nil {
+		return err
+	}
+	return generateToPath(data, outPath, opts)
+}
+
+// FetchOnChainIDL returns the IDL JSON stored in the Anchor IDL account of
+// programID, inflating the zlib-compressed account payload.
+func FetchOnChainIDL(ctx context.Context, endpoint, programID string) ([]byte, error) {
+	program, err := decodePubkey(programID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid program id %q: %w", programID, err)
+	}
+	address, err := anchorIDLAddress(program)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := getAccountData(ctx, endpoint, encodeBase58(address[:]))
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return nil, fmt.Errorf("program %s has no published IDL account (%s)", programID, encodeBase58(address[:]))
+	}
+	if len(account) < idlAccountHeader {
+		return nil, fmt.Errorf("IDL account of %s is too short (%d bytes)", programID, len(account))
+	}
+	size := int(binary.LittleEndian.Uint32(account[idlAccountHeader-4:]))
+	if size > len(account)-idlAccountHeader {
+		return nil, fmt.Errorf("IDL account of %s is truncated: want %d bytes of data, have %d", programID, size, len(account)-idlAccountHeader)
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(account[idlAccountHeader : idlAccountHeader+size]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate IDL: %w", err)
+	}
+	defer r.Close()
+	idl, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inflate IDL: %w", err)
+	}
+	return idl, nil
+}
+
+// getAccountData fetches the data of an account with getAccountInfo, or nil
+// when the account does not exist.
+func getAccountData(ctx context.Context, endpoint, address string) ([]byte, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "getAccountInfo",
+		"params":  []interface{}{address, map[string]string{"encoding": "base64"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch IDL account: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch IDL account from %s: %s", endpoint, resp.Status)
+	}
+
+	var reply struct {
+		Result *struct {
+			Value *struct {
+				Data []string `json:"data"`
+			} `json:"value"`
+		} `json:"result"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("failed to parse RPC response: %w", err)
+	}
+	if reply.Error != nil {
+		return nil, fmt.Errorf("RPC error %d: %s", reply.Error.Code, reply.Error.Message)
+	}
+	if reply.Result == nil || reply.Result.Value == nil {
+		return nil, nil
+	}
+	if len(reply.Result.Value.Data) == 0 {
+		return nil, errors.New("RPC response carries no account data")
+	}
+	return base64.StdEncoding.DecodeString(reply.Result.Value.Data[0])
+}
+
+// anchorIDLAddress derives the Anchor IDL account: createWithSeed from the
+// program's seedless PDA with the "anchor:idl" seed, owned by the program.
+func anchorIDLAddress(program [32]byte) ([32]byte, error) {
+	base, err := findProgramAddress(nil, program)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	h := sha256.New()
+	h.Write(base[:])
+	h.Write([]byte(anchorIDLSeed))
+	h.Write(program[:])
+	var address [32]byte
+	copy(address[:], h.Sum(nil))
+	return address, nil
+}
+
+// findProgramAddress returns the first off-curve program address for seeds,
+// trying bump seeds from 255 down.
+func findProgramAddress(seeds [][]byte, program [32]byte) ([32]byte, error) {
+	for bump := 255; bump >= 0; bump-- {
+		h := sha256.New()
+		for _, seed := range seeds {
+			h.Write(seed)
+		}
+		h.Write([]byte{byte(bump)})
+		h.Write(program[:])
+		h.Write([]byte("ProgramDerivedAddress"))
+		var address [32]byte
+		copy(address[:], h.Sum(nil))
+		if !isOnCurve(address) {
+			return address, nil
+		}
+	}
+	return [32]byte{}, errors.New("unable to find a valid program address")
+}
+
+var (
+	curveP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	curveD = func() *big.Int {
+		// d = -121665 / 121666 mod p
+		d := new(big.Int).ModInverse(big.NewInt(121666), curveP)
+		d.Mul(d, big.NewInt(-121665))
+		return d.Mod(d, curveP)
+	}()
+)
+
+// isOnCurve reports whether the compressed point decompresses to an ed25519
+// curve point, i.e. whether x² = (y² - 1) / (d·y² + 1) has a solution.
+func isOnCurve(point [32]byte) bool {
+	var be [32]byte
+	for i, b := range point {
+		be[31-i] = b
+	}
+	be[0] &= 0x7f
+	y := new(big.Int).SetBytes(be[:])
+	y.Mod(y, curveP)
+
+	y2 := new(big.Int).Mul(y, y)
+	u := new(big.Int).Sub(y2, big.NewInt(1))
+	u.Mod(u, curveP)
+	v := new(big.Int).Mul(curveD, y2)
+	v.Add(v, big.NewInt(1))
+	v.Mod(v, curveP)
+
+	x2 := new(big.Int).ModInverse(v, curveP)
+	x2.Mul(x2, u)
+	x2.Mod(x2, curveP)
+	if x2.Sign() == 0 {
+		return true
+	}
+	// Euler's criterion: x2 is a square iff x2^((p-1)/2) == 1.
+	exp := new(big.Int).Rsh(curveP, 1)
+	return new(big.Int).Exp(x2, exp, curveP).Cmp(big.NewInt(1)) == 0
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// decodePubkey decodes a base58 public key.
+func decodePubkey(s string) ([32]byte, error) {
+	var key [32]byte
+	n := new(big.Int)
+	for _, r := range s {
+		i := strings.IndexRune(base58Alphabet, r)
+		if i < 0 {
+			return key, fmt.Errorf("invalid base58 character %q", r)
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	zeros := len(s) - len(strings.TrimLeft(s, "1"))
+	b := n.Bytes()
+	if zeros+len(b) != len(key) {
+		return key, fmt.Errorf("decoded length %d, want %d", zeros+len(b), len(key))
+	}
+	copy(key[zeros:], b)
+	return key, nil
+}
+
+// encodeBase58 encodes b in base58.
+func encodeBase58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, big.NewInt(58), mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,174 @@
+package idlgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// --- Protobuf Output ---
+
+// GenerateProto writes proto3 message definitions mirroring the types,
+// accounts and events of the IDL at idlPath to outPath, so schemas of
+// decoded data can be shared over gRPC. Integers wider than 64 bits,
+// pubkeys and types proto3 cannot nest (vecs of vecs, tuples) become bytes.
+func GenerateProto(idlPath, outPath string, opts Options) error {
+	if idlPath == "" || outPath == "" {
+		return fmt.Errorf("idl and proto paths are required")
+	}
+	data, err := readInput(idlPath)
+	if err != nil {
+		return err
+	}
+	idl, err := parseIDL(data)
+	if err != nil {
+		return err
+	}
+	return writeOutput(outPath, generateProto(idl, opts))
+}
+
+// generateProto renders the proto3 file for idl.
+func generateProto(idl IDL, opts Options) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s// Code generated by idlgen %s. DO NOT EDIT.\n\n", banner(opts.Header), version())
+	buf.WriteString("syntax = \"proto3\";\n")
+	pkg := opts.PackageName
+	if pkg == "" {
+		pkg = "main"
+	}
+	fmt.Fprintf(&buf, "\npackage %s;\n", pkg)
+
+	for _, t := range idl.Types {
+		buf.WriteString("\n")
+		if t.Type.Kind == "enum" {
+			writeProtoEnum(&buf, toPascalCase(t.Name), t.Type.Variants)
+		} else {
+			writeProtoMessage(&buf, toPascalCase(t.Name), t.Type.Fields, "")
+		}
+	}
+	for _, a := range idl.Accounts {
+		if a.Type != nil && len(a.Type.Fields) > 0 {
+			buf.WriteString("\n")
+			writeProtoMessage(&buf, toPascalCase(a.Name), a.Type.Fields, "")
+		}
+	}
+	for _, e := range idl.Events {
+		if len(e.Fields) > 0 {
+			buf.WriteString("\n")
+			writeProtoMessage(&buf, toPascalCase(e.Name), e.Fields, "")
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeProtoMessage writes a message with one field per IDL field, numbered
+// in Borsh order.
+func writeProtoMessage(buf *bytes.Buffer, name string, fields []IdlField, indent string) {
+	fmt.Fprintf(buf, "%smessage %s {\n", indent, name)
+	for i, f := range fields {
+		fmt.Fprintf(buf, "%s  %s %s = %d;\n", indent, protoFieldType(f.Type), toSnakeCase(f.Name), i+1)
+	}
+	fmt.Fprintf(buf, "%s}\n", indent)
+}
+
+// writeProtoEnum writes a simple enum as a proto enum, whose values are
+// prefixed with the enum name since proto3 enum values share the package
+// scope. Enums with fields become a message holding a oneof of one message
+// per variant.
+func writeProtoEnum(buf *bytes.Buffer, name string, variants []IdlVariant) {
+	if !isComplexEnum(variants) {
+		fmt.Fprintf(buf, "enum %s {\n", name)
+		for i, v := range variants {
+			fmt.Fprintf(buf, "  %s_%s = %d;\n", strings.ToUpper(toSnakeCase(name)), strings.ToUpper(toSnakeCase(v.Name)), i)
+		}
+		buf.WriteString("}\n")
+		return
+	}
+	fmt.Fprintf(buf, "message %s {\n", name)
+	for _, v := range variants {
+		writeProtoMessage(buf, toPascalCase(v.Name), enumFields(v.Fields), "  ")
+	}
+	buf.WriteString("  oneof variant {\n")
+	for i, v := range variants {
+		fmt.Fprintf(buf, "    %s %s = %d;\n", toPascalCase(v.Name), toSnakeCase(v.Name), i+1)
+	}
+	buf.WriteString("  }\n}\n")
+}
+
+// protoFieldType maps an IDL field type to a proto3 field type, including
+// its repeated or optional label.
+func protoFieldType(t IdlType) string {
+	switch {
+	case t.Option != nil:
+		inner := innerType(*t.Option)
+		if protoRepeated(inner) {
+			return protoFieldType(inner) // repeated fields cannot be optional
+		}
+		return "optional " + protoScalar(inner)
+	case t.Coption != nil:
+		inner := innerType(*t.Coption)
+		if protoRepeated(inner) {
+			return protoFieldType(inner)
+		}
+		return "optional " + protoScalar(inner)
+	case t.Vec != nil && !isByte(innerType(*t.Vec)):
+		return "repeated " + protoScalar(innerType(*t.Vec))
+	case t.Array != nil && !isByte(innerType((*t.Array)[0])):
+		return "repeated " + protoScalar(innerType((*t.Array)[0]))
+	case t.Map != nil:
+		key, value := innerType((*t.Map)[0]), innerType((*t.Map)[1])
+		if k := protoScalar(key); protoMapKey(k) && !protoRepeated(value) {
+			return "map<" + k + ", " + protoScalar(value) + ">"
+		}
+		return "bytes"
+	}
+	return protoScalar(t)
+}
+
+// protoRepeated reports whether t maps to a repeated proto field.
+func protoRepeated(t IdlType) bool {
+	return strings.HasPrefix(protoFieldType(t), "repeated ")
+}
+
+// protoScalar maps t to a proto3 type that can stand alone, as an element of
+// a repeated field or a map value. Byte vecs and arrays and the types proto3
+// cannot nest become bytes holding their Borsh encoding.
+func protoScalar(t IdlType) string {
+	switch {
+	case t.Defined != nil:
+		return toPascalCase(*t.Defined)
+	case t.Primitive != "":
+		switch t.Primitive {
+		case "bool", "string", "bytes":
+			return t.Primitive
+		case "u8", "u16", "u32":
+			return "uint32"
+		case "i8", "i16", "i32":
+			return "int32"
+		case "u64":
+			return "uint64"
+		case "i64":
+			return "int64"
+		case "f32":
+			return "float"
+		case "f64":
+			return "double"
+		}
+		return "bytes" // pubkeys, 128/256-bit integers and unknown primitives
+	}
+	return "bytes"
+}
+
+// protoMapKey reports whether a proto3 scalar type may key a map.
+func protoMapKey(protoType string) bool {
+	switch protoType {
+	case "bool", "string", "uint32", "int32", "uint64", "int64":
+		return true
+	}
+	return false
+}
+
+// isByte reports whether t is u8, so vecs and arrays of it map to bytes.
+func isByte(t IdlType) bool {
+	return t.Primitive == "u8"
+}
@@ -0,0 +1,41 @@
+package idlgen
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGenerateProtoMessage(t *testing.T) {
+	idl, err := parseIDL(structIDL("Pool", `{"name": "liquidity", "type": "u64"}`, `{"name": "authority", "type": "pubkey"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto := string(generateProto(idl, Options{PackageName: "pool"}))
+	assertContains(t, proto,
+		"syntax = \"proto3\";\n",
+		"package pool;\n",
+		"message Pool {\n  uint64 liquidity = 1;\n  bytes authority = 2;\n}\n",
+	)
+}
+
+func TestProtoReadsInputOnce(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write(structIDL("Pool", `{"name": "liquidity", "type": "u64"}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	protoPath := filepath.Join(dir, "pool.proto")
+	if err := GenerateWithOptions(srv.URL+"/pool.json", filepath.Join(dir, "pool.go"), Options{ProtoPath: protoPath}); err != nil {
+		t.Fatal(err)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("IDL fetched %d times, want once", n)
+	}
+	assertContains(t, readFile(t, protoPath), "message Pool {\n  uint64 liquidity = 1;\n}\n")
+}
@@ -0,0 +1,219 @@
+package idlgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- Split Output ---
+
+// splitFiles are the files written in split mode, in output order.
+var splitFiles = []string{"types.go", "accounts.go", "instructions.go", "errors.go", "client.go"}
+
+// splitSections maps the "// --- Name ---" sections of the generated code to
+// their file. Declarations before the first section (the program ID) and
+// unlisted sections go to types.go.
+var splitSections = map[string]string{
+	"Errors":                 "errors.go",
+	"Accounts":               "accounts.go",
+	"SPL Helpers":            "accounts.go",
+	"Instructions":           "instructions.go",
+	"Transaction Builder":    "instructions.go",
+	"Discriminator Registry": "instructions.go",
+	"Client":                 "client.go",
+}
+
+var sectionMarker = regexp.MustCompile(`^// --- (.+) ---$`)
+
+// GenerateSplit generates bindings from the IDL at idlPath into outDir as
+// separate types.go, accounts.go, instructions.go, errors.go and client.go
+// files of one package. Files left without declarations are not written, and
+// stale generated copies of them are removed.
+func GenerateSplit(idlPath, outDir string, opts Options) error {
+	if idlPath == "" || outDir == "" {
+		return fmt.Errorf("idl path and out dir are required")
+	}
+
+	data, err := readInput(idlPath)
+	if err != nil {
+		return err
+	}
+	if opts.ProgramName == "" && idlPath != stdioPath {
+		opts.ProgramName = programNameFromPath(idlPath)
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	typesPath := filepath.Join(outDir, splitFiles[0])
+	if opts.Incremental && !opts.Force {
+		if existing, err := os.ReadFile(typesPath); err == nil && bytes.Contains(existing, []byte(sumLine(inputSum(data, opts)))) {
+			if opts.Verbose {
+				log.Println("Up to date:", outDir)
+			}
+			return writeSchemas(data, opts)
+		}
+	}
+
+	code, err := generate(data, opts)
+	if err != nil {
+		var fe *FormatError
+		if errors.As(err, &fe) {
+			debugPath := typesPath + ".debug"
+			if os.WriteFile(debugPath, fe.Source, 0644) == nil {
+				fe.DebugPath = debugPath
+			}
+		}
+		return err
+	}
+
+	files, err := splitSource(code)
+	if err != nil {
+		return err
+	}
+	for _, name := range splitFiles {
+		outPath := filepath.Join(outDir, name)
+		src, ok := files[name]
+		if !ok {
+			if existing, err := os.ReadFile(outPath); err == nil && bytes.Contains(existing, []byte("// Code generated by idlgen")) {
+				if err := os.Remove(outPath); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := os.WriteFile(outPath, src, 0644); err != nil {
+			return err
+		}
+	}
+	return writeSchemas(data, opts)
+}
+
+// splitSource distributes the top-level declarations of a generated file
+// over splitFiles by section. Each file repeats the header and package clause
+// and imports only the packages it references.
+func splitSource(code []byte) (map[string][]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", code, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse generated code: %w", err)
+	}
+	offset := func(p token.Pos) int { return fset.Position(p).Offset }
+
+	var markers []*ast.Comment
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if sectionMarker.MatchString(c.Text) {
+				markers = append(markers, c)
+			}
+		}
+	}
+
+	header := string(code[:offset(file.Package)])
+	var imports []*ast.ImportSpec
+	bodies := make(map[string]*strings.Builder)
+	uses := make(map[string]map[string]bool)
+	start := offset(file.Name.End())
+	for _, decl := range file.Decls {
+		end := offset(decl.End())
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			for _, spec := range gen.Specs {
+				imports = append(imports, spec.(*ast.ImportSpec))
+			}
+			start = end
+			continue
+		}
+
+		name := splitFiles[0]
+		for _, m := range markers {
+			if m.Pos() < decl.Pos() {
+				if f, ok := splitSections[sectionMarker.FindStringSubmatch(m.Text)[1]]; ok {
+					name = f
+				} else {
+					name = splitFiles[0]
+				}
+			}
+		}
+		if bodies[name] == nil {
+			bodies[name] = new(strings.Builder)
+			uses[name] = make(map[string]bool)
+		}
+		// The chunk since the previous declaration carries its section
+		// marker and doc comment along with it.
+		bodies[name].WriteString("\n\n")
+		bodies[name].WriteString(strings.TrimSpace(string(code[start:end])))
+		selectorNames(decl, uses[name])
+		start = end
+	}
+
+	files := make(map[string][]byte, len(bodies))
+	for name, body := range bodies {
+		var std, other []string
+		for _, spec := range imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			local := importName(importPath)
+			line := spec.Path.Value
+			if spec.Name != nil {
+				local = spec.Name.Name
+				line = spec.Name.Name + " " + line
+			}
+			if !uses[name][local] {
+				continue
+			}
+			if strings.Contains(strings.SplitN(importPath, "/", 2)[0], ".") {
+				other = append(other, line)
+			} else {
+				std = append(std, line)
+			}
+		}
+
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "%spackage %s\n", header, file.Name.Name)
+		if len(std)+len(other) > 0 {
+			buf.WriteString("\nimport (\n")
+			for _, line := range std {
+				buf.WriteString("\t" + line + "\n")
+			}
+			if len(std) > 0 && len(other) > 0 {
+				buf.WriteString("\n")
+			}
+			for _, line := range other {
+				buf.WriteString("\t" + line + "\n")
+			}
+			buf.WriteString(")\n")
+		}
+		buf.WriteString(body.String())
+		buf.WriteString("\n")
+
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, newFormatError(err, buf.Bytes())
+		}
+		files[name] = src
+	}
+	return files, nil
+}
+
+// importName guesses the package name of an unaliased import from its path,
+// e.g. "github.com/gagliardetto/solana-go" -> "solana".
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	return name
+}
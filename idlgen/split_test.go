@@ -0,0 +1,32 @@
+package idlgen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateSplit(t *testing.T) {
+	idlPath, outDir := filepath.Join(t.TempDir(), "test.json"), t.TempDir()
+	data := testIDL(
+		`"instructions": [{"name": "deposit", "accounts": [{"name": "vault", "writable": true}], "args": [{"name": "amount", "type": "u64"}]}]`,
+		`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`,
+		`"errors": [{"code": 6000, "name": "Overflow", "msg": "Math overflow"}]`,
+	)
+	if err := os.WriteFile(idlPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := GenerateSplit(idlPath, outDir, Options{PackageName: "bindings"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range splitFiles {
+		path := filepath.Join(outDir, name)
+		// Each file carries its own pruned imports, so it parses on its own.
+		if _, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.AllErrors); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	testModule(t, outDir)
+}
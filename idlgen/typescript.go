@@ -0,0 +1,153 @@
+package idlgen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// --- TypeScript Output ---
+
+// GenerateTypeScript writes TypeScript definitions for the types, accounts,
+// instruction args and events of the IDL at idlPath to outPath, so frontends
+// share the shapes of the Go bindings. Integers of 64 bits and more are
+// bigint and pubkeys their base58 string.
+func GenerateTypeScript(idlPath, outPath string, opts Options) error {
+	if idlPath == "" || outPath == "" {
+		return fmt.Errorf("idl and ts paths are required")
+	}
+	data, err := readInput(idlPath)
+	if err != nil {
+		return err
+	}
+	idl, err := parseIDL(data)
+	if err != nil {
+		return err
+	}
+	return writeOutput(outPath, generateTypeScript(idl, opts))
+}
+
+// generateTypeScript renders the TypeScript definitions for idl.
+func generateTypeScript(idl IDL, opts Options) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s// Code generated by idlgen %s. DO NOT EDIT.\n", banner(opts.Header), version())
+
+	for _, t := range idl.Types {
+		buf.WriteString("\n")
+		if t.Type.Kind == "enum" {
+			writeTSEnum(&buf, toPascalCase(t.Name), t.Type.Variants)
+		} else {
+			writeTSInterface(&buf, toPascalCase(t.Name), t.Type.Fields)
+		}
+	}
+	for _, a := range idl.Accounts {
+		if a.Type != nil && len(a.Type.Fields) > 0 {
+			buf.WriteString("\n")
+			writeTSInterface(&buf, toPascalCase(a.Name), a.Type.Fields)
+		}
+	}
+	for _, instr := range idl.Instructions {
+		buf.WriteString("\n")
+		writeTSInterface(&buf, toPascalCase(instr.Name)+"Args", instr.Args)
+	}
+	for _, e := range idl.Events {
+		if len(e.Fields) > 0 {
+			buf.WriteString("\n")
+			writeTSInterface(&buf, toPascalCase(e.Name), e.Fields)
+		}
+	}
+	return buf.Bytes()
+}
+
+// writeTSInterface writes an exported interface with one property per field.
+func writeTSInterface(buf *bytes.Buffer, name string, fields []IdlField) {
+	if len(fields) == 0 {
+		fmt.Fprintf(buf, "export interface %s {}\n", name)
+		return
+	}
+	fmt.Fprintf(buf, "export interface %s {\n", name)
+	for _, f := range fields {
+		fmt.Fprintf(buf, "  %s: %s;\n", tagName(f.Name, TagCaseCamel), tsType(f.Type))
+	}
+	buf.WriteString("}\n")
+}
+
+// writeTSEnum writes a simple enum as a union of its variant names, and an
+// enum with fields as a union of objects tagged by a "kind" property.
+func writeTSEnum(buf *bytes.Buffer, name string, variants []IdlVariant) {
+	if !isComplexEnum(variants) {
+		names := make([]string, len(variants))
+		for i, v := range variants {
+			names[i] = fmt.Sprintf("%q", v.Name)
+		}
+		fmt.Fprintf(buf, "export type %s = %s;\n", name, strings.Join(names, " | "))
+		return
+	}
+	fmt.Fprintf(buf, "export type %s =\n", name)
+	for i, v := range variants {
+		props := []string{fmt.Sprintf("kind: %q", v.Name)}
+		for _, f := range v.Fields {
+			props = append(props, fmt.Sprintf("%s: %s", tagName(f.Name, TagCaseCamel), tsType(f.Type)))
+		}
+		end := ""
+		if i == len(variants)-1 {
+			end = ";"
+		}
+		fmt.Fprintf(buf, "  | { %s }%s\n", strings.Join(props, "; "), end)
+	}
+}
+
+// tsType maps an IDL type to a TypeScript type.
+func tsType(t IdlType) string {
+	switch {
+	case t.Primitive != "":
+		switch t.Primitive {
+		case "bool":
+			return "boolean"
+		case "u8", "i8", "u16", "i16", "u32", "i32", "f32", "f64":
+			return "number"
+		case "u64", "i64", "u128", "i128", "u256", "i256":
+			return "bigint"
+		case "string", "pubkey", "publicKey":
+			return "string"
+		case "bytes":
+			return "Uint8Array"
+		}
+		return "unknown"
+	case t.Defined != nil:
+		return toPascalCase(*t.Defined)
+	case t.Option != nil:
+		return tsNullable(tsType(innerType(*t.Option)))
+	case t.Coption != nil:
+		return tsNullable(tsType(innerType(*t.Coption)))
+	case t.Vec != nil:
+		return tsArray(tsType(innerType(*t.Vec)))
+	case t.Array != nil:
+		return tsArray(tsType(innerType((*t.Array)[0])))
+	case t.Map != nil:
+		return "Map<" + tsType(innerType((*t.Map)[0])) + ", " + tsType(innerType((*t.Map)[1])) + ">"
+	case t.Tuple != nil:
+		elems := make([]string, len(t.Tuple))
+		for i, elem := range t.Tuple {
+			elems[i] = tsType(innerType(elem))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return "unknown"
+}
+
+// tsNullable returns elem | null; nested options collapse into one null.
+func tsNullable(elem string) string {
+	if strings.HasSuffix(elem, " | null") {
+		return elem
+	}
+	return elem + " | null"
+}
+
+// tsArray returns the array type of elem, parenthesizing unions.
+func tsArray(elem string) string {
+	if strings.Contains(elem, " | ") {
+		return "(" + elem + ")[]"
+	}
+	return elem + "[]"
+}
@@ -0,0 +1,37 @@
+package idlgen
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateTypeScriptInterface(t *testing.T) {
+	idl, err := parseIDL(testIDL(
+		`"instructions": [{"name": "deposit", "accounts": [], "args": [{"name": "amount", "type": "u64"}]}]`,
+		`"types": [`+structDef("Pool",
+			`{"name": "total_liquidity", "type": "u64"}`,
+			`{"name": "authority", "type": "pubkey"}`,
+			`{"name": "fee_bps", "type": {"option": "u16"}}`,
+			`{"name": "signers", "type": {"vec": "pubkey"}}`,
+		)+`]`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := string(generateTypeScript(idl, Options{}))
+	assertContains(t, ts,
+		"export interface Pool {\n  totalLiquidity: bigint;\n  authority: string;\n  feeBps: number | null;\n  signers: string[];\n}\n",
+		"export interface DepositArgs {\n  amount: bigint;\n}\n",
+	)
+}
+
+func TestGenerateDirTypeScript(t *testing.T) {
+	idlDir, outDir, tsDir := t.TempDir(), t.TempDir(), filepath.Join(t.TempDir(), "ts")
+	writeIDLs(t, idlDir, map[string][]byte{
+		"pool": structIDL("Pool", `{"name": "liquidity", "type": "u64"}`),
+	})
+	if err := GenerateDir(idlDir, outDir, Options{TSPath: tsDir}); err != nil {
+		t.Fatal(err)
+	}
+	assertContains(t, readFile(t, filepath.Join(tsDir, "pool.ts")), "export interface Pool {\n  liquidity: bigint;\n}\n")
+}
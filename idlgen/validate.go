@@ -0,0 +1,272 @@
+package idlgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// --- Validation ---
+
+// checkIdentifiers reports IDL names that collapse to the same Go identifier
+// after conversion (e.g. "my_state" and "myState" both become MyState), which
+// would otherwise surface as a confusing redeclaration error in the output.
+func checkIdentifiers(idl IDL, prefix string) error {
+	sources := make(map[string][]string)
+	add := func(ident, source string) {
+		sources[ident] = append(sources[ident], source)
+	}
+
+	for _, t := range idl.Types {
+		add(prefix+toPascalCase(t.Name), fmt.Sprintf("type %q", t.Name))
+	}
+	for _, a := range idl.Accounts {
+		add(prefix+toPascalCase(a.Name)+"Discriminator", fmt.Sprintf("account %q", a.Name))
+		if a.Type != nil && len(a.Type.Fields) > 0 {
+			add(prefix+toPascalCase(a.Name), fmt.Sprintf("account %q", a.Name))
+		}
+	}
+	for _, e := range idl.Events {
+		add(prefix+"Event"+toPascalCase(e.Name)+"Discriminator", fmt.Sprintf("event %q", e.Name))
+		if len(e.Fields) > 0 {
+			add(prefix+toPascalCase(e.Name), fmt.Sprintf("event %q", e.Name))
+		}
+	}
+	for _, instr := range idl.Instructions {
+		source := fmt.Sprintf("instruction %q", instr.Name)
+		for _, suffix := range []string{"Args", "Accounts", "Discriminator"} {
+			add(prefix+toPascalCase(instr.Name)+suffix, source)
+		}
+	}
+	for _, e := range idl.Errors {
+		add("Err"+prefix+toPascalCase(e.Name), fmt.Sprintf("error %q", e.Name))
+	}
+	for _, c := range idl.Constants {
+		add(prefix+toPascalCase(c.Name), fmt.Sprintf("constant %q", c.Name))
+	}
+	for key, value := range map[string]string{"Version": idl.Metadata.Version, "Spec": idl.Metadata.Spec, "Repository": idl.Metadata.Repository} {
+		if value != "" {
+			add(prefix+key, "metadata "+strings.ToLower(key))
+		}
+	}
+
+	var collisions []string
+	for ident, srcs := range sources {
+		if len(srcs) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s: %s", ident, strings.Join(srcs, ", ")))
+		}
+	}
+	if len(collisions) == 0 {
+		return nil
+	}
+	sort.Strings(collisions)
+	return fmt.Errorf("IDL names collide after conversion to Go identifiers:\n  %s", strings.Join(collisions, "\n  "))
+}
+
+// knownPrimitives are the IDL primitives mapType translates; anything else
+// falls back to interface{}.
+var knownPrimitives = map[string]bool{
+	"bool": true, "u8": true, "i8": true, "u16": true, "i16": true,
+	"u32": true, "i32": true, "u64": true, "i64": true, "u128": true, "i128": true,
+	"u256": true, "i256": true, "f32": true, "f64": true,
+	"bytes": true, "string": true, "pubkey": true, "publicKey": true,
+}
+
+// unknownPrimitives lists the primitives neither mapType nor primitiveMap
+// knows, each with the field or argument it appears in.
+func unknownPrimitives(idl IDL, primitiveMap map[string]string) []string {
+	var found []string
+	walkFieldTypes(idl, func(t IdlType, source string) {
+		if t.Primitive != "" && !knownPrimitives[t.Primitive] && primitiveMap[t.Primitive] == "" {
+			found = append(found, fmt.Sprintf("%s: unknown primitive %q", source, t.Primitive))
+		}
+	})
+	return found
+}
+
+// missingDiscriminators lists the instructions and accounts whose
+// discriminator the IDL omits, which would otherwise be derived from their
+// names.
+func missingDiscriminators(idl IDL) []string {
+	var found []string
+	for _, instr := range idl.Instructions {
+		if len(instr.Discriminator) == 0 {
+			found = append(found, fmt.Sprintf("instruction %q", instr.Name))
+		}
+	}
+	for _, a := range idl.Accounts {
+		if len(a.Discriminator) == 0 {
+			found = append(found, fmt.Sprintf("account %q", a.Name))
+		}
+	}
+	return found
+}
+
+// undefinedTypes lists the defined references that name neither a type, an
+// account or event with inline fields, nor a type mapped through typeMap.
+func undefinedTypes(idl IDL, typeMap map[string]string) ([]string, map[string]bool) {
+	known := definedNames(idl)
+	var found []string
+	names := make(map[string]bool)
+	walkFieldTypes(idl, func(t IdlType, source string) {
+		if t.Defined == nil || known[*t.Defined] || typeMap[*t.Defined] != "" {
+			return
+		}
+		found = append(found, fmt.Sprintf("%s: undefined type %q", source, *t.Defined))
+		names[*t.Defined] = true
+	})
+	return found, names
+}
+
+// definedNames returns the names a defined reference can resolve to: the
+// types, and the accounts and events that carry their fields inline.
+func definedNames(idl IDL) map[string]bool {
+	known := make(map[string]bool)
+	for _, t := range idl.Types {
+		known[t.Name] = true
+	}
+	for _, a := range idl.Accounts {
+		if a.Type != nil && len(a.Type.Fields) > 0 {
+			known[a.Name] = true
+		}
+	}
+	for _, e := range idl.Events {
+		if len(e.Fields) > 0 {
+			known[e.Name] = true
+		}
+	}
+	return known
+}
+
+// ValidateIDL checks idl for problems that would otherwise surface as
+// confusing errors or silently wrong bindings: a missing or malformed
+// program address, unnamed instructions, types, accounts and events,
+// discriminators that are not bytes or differ in length within a section,
+// and defined references that resolve to nothing. It returns every problem
+// found, or nil.
+func ValidateIDL(idl IDL) []error {
+	return validateIDL(idl, nil)
+}
+
+// validateIDL is ValidateIDL, additionally treating the defined references
+// for which resolved returns true as valid.
+func validateIDL(idl IDL, resolved func(name string) bool) []error {
+	var problems []error
+
+	address := idl.Address
+	if address == "" {
+		address = idl.Metadata.Address
+	}
+	if address == "" {
+		problems = append(problems, fmt.Errorf("missing program address (expected \"address\" or \"metadata.address\")"))
+	} else if _, err := decodePubkey(address); err != nil {
+		problems = append(problems, fmt.Errorf("program address %q: %v", address, err))
+	}
+
+	checkName := func(kind, name string, index int) {
+		if strings.TrimSpace(name) == "" {
+			problems = append(problems, fmt.Errorf("%s #%d has no name", kind, index))
+		}
+	}
+	// Discriminators within a section are matched against the same data
+	// prefix, so they must all have the length of the first one.
+	checkDiscriminators := func(kind string, names []string, discs [][]int) {
+		want := 0
+		for i, d := range discs {
+			if len(d) == 0 {
+				continue
+			}
+			for _, b := range d {
+				if b < 0 || b > 255 {
+					problems = append(problems, fmt.Errorf("%s %q: discriminator value %d is not a byte", kind, names[i], b))
+					break
+				}
+			}
+			if want == 0 {
+				want = len(d)
+			} else if len(d) != want {
+				problems = append(problems, fmt.Errorf("%s %q: discriminator has %d bytes, others have %d", kind, names[i], len(d), want))
+			}
+		}
+	}
+
+	var names []string
+	var discs [][]int
+	for i, instr := range idl.Instructions {
+		checkName("instruction", instr.Name, i)
+		names = append(names, instr.Name)
+		discs = append(discs, instr.Discriminator)
+	}
+	checkDiscriminators("instruction", names, discs)
+
+	names, discs = nil, nil
+	for i, a := range idl.Accounts {
+		checkName("account", a.Name, i)
+		names = append(names, a.Name)
+		discs = append(discs, a.Discriminator)
+	}
+	checkDiscriminators("account", names, discs)
+
+	names, discs = nil, nil
+	for i, e := range idl.Events {
+		checkName("event", e.Name, i)
+		names = append(names, e.Name)
+		discs = append(discs, e.Discriminator)
+	}
+	checkDiscriminators("event", names, discs)
+
+	for i, t := range idl.Types {
+		checkName("type", t.Name, i)
+	}
+
+	known := definedNames(idl)
+	walkFieldTypes(idl, func(t IdlType, source string) {
+		if t.Defined == nil || known[*t.Defined] || (resolved != nil && resolved(*t.Defined)) {
+			return
+		}
+		problems = append(problems, fmt.Errorf("%s: undefined type %q", source, *t.Defined))
+	})
+	return problems
+}
+
+// walkFieldTypes calls fn for every type, at any nesting depth, of the
+// fields, variant fields and instruction args and returns of the IDL, along
+// with a description of where it appears. Constants are skipped: numeric ones
+// of unknown type (usize) are emitted untyped.
+func walkFieldTypes(idl IDL, fn func(t IdlType, source string)) {
+	visit := func(t IdlType, source string) {
+		walkType(t, func(t IdlType) {
+			fn(t, source)
+		})
+	}
+	visitFields := func(fields []IdlField, owner string) {
+		for _, f := range fields {
+			visit(f.Type, fmt.Sprintf("%s field %q", owner, f.Name))
+		}
+	}
+
+	for _, t := range idl.Types {
+		owner := fmt.Sprintf("type %q", t.Name)
+		visitFields(t.Type.Fields, owner)
+		for _, v := range t.Type.Variants {
+			visitFields(enumFields(v.Fields), fmt.Sprintf("%s variant %q", owner, v.Name))
+		}
+	}
+	for _, a := range idl.Accounts {
+		if a.Type != nil {
+			visitFields(a.Type.Fields, fmt.Sprintf("account %q", a.Name))
+		}
+	}
+	for _, e := range idl.Events {
+		visitFields(e.Fields, fmt.Sprintf("event %q", e.Name))
+	}
+	for _, instr := range idl.Instructions {
+		owner := fmt.Sprintf("instruction %q", instr.Name)
+		for _, arg := range instr.Args {
+			visit(arg.Type, fmt.Sprintf("%s arg %q", owner, arg.Name))
+		}
+		if instr.Returns != nil {
+			visit(*instr.Returns, owner+" return")
+		}
+	}
+}
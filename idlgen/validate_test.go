@@ -0,0 +1,87 @@
+package idlgen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckIdentifiersCollision(t *testing.T) {
+	data := testIDL(`"types": [` + structDef("pool_state") + `, ` + structDef("PoolState") + `]`)
+	_, err := generate(data, Options{})
+	if err == nil {
+		t.Fatal("generate accepted colliding type names")
+	}
+	if want := `TestPoolState: type "pool_state", type "PoolState"`; !strings.Contains(err.Error(), want) {
+		t.Errorf("error %q does not contain %q", err, want)
+	}
+}
+
+func TestValidateIDL(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{
+			name: "missing address",
+			data: []byte(`{"name": "test", "instructions": []}`),
+			want: "missing program address",
+		},
+		{
+			name: "unresolved defined reference",
+			data: structIDL("Pool", `{"name": "config", "type": {"defined": "Config"}}`),
+			want: `undefined type "Config"`,
+		},
+		{
+			name: "zero-length instruction name",
+			data: testIDL(`"instructions": [{"name": "", "accounts": [], "args": []}]`),
+			want: "instruction #0 has no name",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idl, err := parseIDL(tc.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems := ValidateIDL(idl)
+			if len(problems) != 1 || !strings.Contains(problems[0].Error(), tc.want) {
+				t.Errorf("got problems %v, want one containing %q", problems, tc.want)
+			}
+			if _, err := generate(tc.data, Options{Strict: true}); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("strict generate returned %v, want an error containing %q", err, tc.want)
+			}
+		})
+	}
+	idl, err := parseIDL(structIDL("Pool", `{"name": "liquidity", "type": "u64"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := ValidateIDL(idl); problems != nil {
+		t.Errorf("valid IDL has problems %v", problems)
+	}
+}
+
+func TestNoDiscriminatorFallback(t *testing.T) {
+	data := testIDL(
+		`"instructions": [
+			{"name": "deposit", "discriminator": [1, 2, 3, 4, 5, 6, 7, 8], "accounts": [], "args": []},
+			{"name": "withdraw", "accounts": [], "args": []}
+		]`,
+		`"accounts": [{"name": "Vault", "type": {"kind": "struct", "fields": [{"name": "amount", "type": "u64"}]}}]`,
+	)
+	_, err := generate(data, Options{NoDiscFallback: true})
+	if err == nil {
+		t.Fatal("generate derived the missing discriminators")
+	}
+	for _, want := range []string{`instruction "withdraw"`, `account "Vault"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not list %s", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "deposit") {
+		t.Errorf("error %q lists deposit, which has a discriminator", err)
+	}
+	if _, err := generate(data, Options{}); err != nil {
+		t.Errorf("generate without the flag failed: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package idlgen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- Verification ---
+
+var (
+	packageClause = regexp.MustCompile(`(?m)^package \w+`)
+	compileError  = regexp.MustCompile(`(?m)^\./bindings\.go:(\d+):(\d+): (.*)$`)
+)
+
+// verifyCode compiles the generated code in a scratch module and reports any
+// compile errors together with the generated declaration they occurred in.
+// It needs a Go toolchain and access to the solana-go modules (module cache
+// or network).
+func verifyCode(code []byte) error {
+	dir, err := os.MkdirTemp("", "idlgen-verify-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	// A library package name avoids the missing func main error for "package main".
+	src := packageClause.ReplaceAll(code, []byte("package bindings"))
+	if err := os.WriteFile(filepath.Join(dir, "bindings.go"), src, 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module idlgenverify\n\ngo 1.22\n"), 0644); err != nil {
+		return err
+	}
+
+	if out, err := runGo(dir, "mod", "tidy"); err != nil {
+		return fmt.Errorf("verify: go mod tidy failed: %v\n%s", err, out)
+	}
+	out, err := runGo(dir, "build", ".")
+	if err == nil {
+		return nil
+	}
+
+	lines := strings.Split(string(src), "\n")
+	var problems []string
+	for _, m := range compileError.FindAllStringSubmatch(string(out), -1) {
+		line, _ := strconv.Atoi(m[1])
+		problem := fmt.Sprintf("line %s: %s", m[1], m[3])
+		if decl := enclosingDeclComment(lines, line); decl != "" {
+			problem += " (in " + decl + ")"
+		}
+		problems = append(problems, problem)
+	}
+	if len(problems) == 0 {
+		return fmt.Errorf("verify: go build failed: %v\n%s", err, out)
+	}
+	return fmt.Errorf("verify: generated code does not compile:\n  %s", strings.Join(problems, "\n  "))
+}
+
+// runGo runs the go command in dir and returns its combined output.
+func runGo(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	return out.Bytes(), err
+}
+
+// enclosingDeclComment returns the doc comment of the top-level declaration
+// containing the 1-based line. Generated doc comments name the IDL element
+// (e.g. "represents the arguments for instruction initialize").
+func enclosingDeclComment(lines []string, line int) string {
+	for i := line - 1; i >= 0 && i < len(lines); i-- {
+		if strings.HasPrefix(lines[i], "// ") {
+			return strings.TrimPrefix(lines[i], "// ")
+		}
+	}
+	return ""
+}
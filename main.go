@@ -1,28 +1,231 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/fakhrilainur/idlgen/idlgen"
 )
 
+// typeMapFlag collects repeated -type-map Name=import/path.GoType values.
+type typeMapFlag map[string]string
+
+func (m typeMapFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for name, goType := range m {
+		pairs = append(pairs, name+"="+goType)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m typeMapFlag) Set(value string) error {
+	name, goType, ok := strings.Cut(value, "=")
+	if !ok || name == "" || goType == "" {
+		return fmt.Errorf("expected Name=import/path.GoType, got %q", value)
+	}
+	m[name] = goType
+	return nil
+}
+
 func main() {
+	typeMap := typeMapFlag{}
+	flag.Var(typeMap, "type-map", "Map an IDL defined type to an external Go type, as Name=import/path.GoType (repeatable)")
+
 	var (
-		idlPath    = flag.String("idl", "", "Path to the IDL JSON file")
-		outPath    = flag.String("out", "", "Path to the output Go file")
+		idlPath    = flag.String("idl", "", "Path or http(s) URL of the IDL JSON file (\"-\" for stdin), or a comma-separated list of IDLs to merge")
+		programID  = flag.String("program", "", "Program ID whose on-chain Anchor IDL is fetched instead of -idl")
+		rpcURL     = flag.String("rpc", idlgen.DefaultRPCEndpoint, "RPC endpoint used with -program")
+		outPath    = flag.String("out", "", "Path to the output Go file (\"-\" for stdout)")
+		diffOld    = flag.String("diff", "", "Print what changed between two IDLs instead of generating: -diff old.json new.json")
+		protoPath  = flag.String("proto", "", "Also write proto3 messages mirroring the types, accounts and events to this path (a directory of <idl-name>.proto files with -idl-dir)")
+		tsPath     = flag.String("ts", "", "Also write TypeScript definitions for the types, accounts, instruction args and events to this path (a directory of <idl-name>.ts files with -idl-dir)")
+		split      = flag.Bool("split", false, "With -idl, write types.go, accounts.go, instructions.go, errors.go and client.go into -out-dir")
+		idlDir     = flag.String("idl-dir", "", "Directory of IDL JSON files to generate in batch")
+		outDir     = flag.String("out-dir", "", "Output directory for batch generation")
+		pkgPerProg = flag.Bool("package-per-program", false, "With -idl-dir, write each program into its own package directory under -out-dir")
+		incr       = flag.Bool("incremental", false, "Skip IDLs whose output already records the same input sha256")
+		force      = flag.Bool("force", false, "Regenerate with -incremental even when the input is unchanged")
+		jobs       = flag.Int("jobs", 0, "Concurrent generations with -idl-dir (default the number of CPUs)")
+		prefix     = flag.String("prefix", "", "Prefix of generated identifiers (default the PascalCase program name)")
 		pkgName    = flag.String("pkg", "main", "Go package name")
-		clientName = flag.String("client", "", "Client struct name (optional)")
+		goVersion  = flag.String("go-version", "", "Go version the generated code targets, e.g. 1.17; below 1.18 generic types are monomorphized (default the running toolchain's)")
+		header     = flag.String("header", "", "Banner above the generated code: a file path or a literal string (lines become // comments)")
+		clientName = flag.String("client", "", "Client struct name (optional, \"none\" to skip the client)")
+		primMap    = flag.String("primitive-map", "", "JSON file mapping IDL primitives to Go types, e.g. {\"pubkey\": \"github.com/org/types.Pubkey\"}; overrides built-ins")
+		u128Type   = flag.String("u128-type", "", "Go type for u128 values, e.g. math/big.Int (default bin.Uint128)")
+		i128Type   = flag.String("i128-type", "", "Go type for i128 values, e.g. math/big.Int (default bin.Int128)")
+		u256Type   = flag.String("u256-type", "", "Go type for u256 values, e.g. github.com/holiman/uint256.Int (default [32]byte, little-endian)")
+		i256Type   = flag.String("i256-type", "", "Go type for i256 values (default [32]byte, little-endian two's complement)")
+		jsonTags   = flag.Bool("json-tags", false, "Emit json struct tags using the IDL field names")
+		tagCase    = flag.String("tag-case", idlgen.TagCaseRaw, "Casing of bin tag names: raw, snake or camel")
+		equal      = flag.Bool("equal", false, "Generate Equal methods comparing structs field by field")
+		validate   = flag.Bool("validate", false, "Generate Validate methods checking enum variants and documented max lengths")
+		describe   = flag.Bool("describe", false, "Generate Describe methods rendering accounts for logs, with pubkeys in base58 and byte slices in hex")
+		enumJSON   = flag.Bool("enum-json", false, "Generate MarshalJSON/UnmarshalJSON encoding enums by variant name")
+		allowUnfmt = flag.Bool("allow-unformatted", false, "Write unformatted code instead of failing when formatting fails")
+		discMode   = flag.String("discriminator-mode", idlgen.DiscriminatorModeAnchor, "Instruction discriminators: anchor (sha256 prefix) or u32le (little-endian u32 tag)")
+		noFallback = flag.Bool("no-discriminator-fallback", false, "Fail when an instruction or account has no discriminator in the IDL instead of deriving one from its name")
+		acctCase   = flag.String("account-discriminator-case", idlgen.AccountCasePascal, "Name casing hashed into account discriminators the IDL omits: pascal (Anchor), snake or raw")
+		instrCase  = flag.String("instruction-discriminator-case", idlgen.InstructionCaseSnake, "Name casing hashed into instruction discriminators the IDL omits: snake (Anchor), camel or raw")
+		instrNS    = flag.String("instruction-namespace", idlgen.DefaultInstructionNamespace, "Namespace hashed before instruction names into discriminators the IDL omits")
+		discArray  = flag.Bool("discriminator-array", false, "Emit discriminators as fixed-size byte arrays instead of []byte slices")
+		ctors      = flag.Bool("constructors", false, "Generate a New<Type> constructor per struct type taking its fields in order")
+		optHelpers = flag.Bool("option-helpers", false, "Generate Has<Field>() bool methods reporting whether the option fields of structs are set")
+		assertions = flag.Bool("assertions", false, "Emit compile-time assertions that generated types implement encoding, fmt and json interfaces")
+		cuHints    = flag.Bool("compute-hints", false, "Emit a <Instr>ComputeUnits constant for instructions whose docs give a compute estimate, e.g. \"Compute units: 45000\"")
+		builders   = flag.Bool("builders", false, "Generate a fluent builder per instruction")
+		splHelpers = flag.Bool("spl-helpers", false, "For programs taking the token program and *TokenAccount accounts, generate an associated token address helper and builder setters")
+		checkAccts = flag.Bool("check-accounts", false, "Make instruction constructors return an error when a required account is unset")
+		binImport  = flag.String("bin-import", "", "Import path of the binary package (default github.com/gagliardetto/binary)")
+		solImport  = flag.String("solana-import", "", "Import path of the solana package (default github.com/gagliardetto/solana-go)")
+		rpcImport  = flag.String("rpc-import", "", "Import path of the rpc package (default github.com/gagliardetto/solana-go/rpc)")
+		minimal    = flag.Bool("minimal", false, "Emit only types, instruction Args/Accounts structs, discriminators and Borsh codecs (no rpc, no instruction constructors)")
+		pubkeyType = flag.String("pubkey-type", "", "Go type for pubkeys with -minimal, e.g. [32]byte (default solana.PublicKey)")
+		inlineArrs = flag.Bool("inline-byte-arrays", false, "Inline types whose only field is a u8 array (e.g. a 32-byte Hash) as [N]byte at their use sites")
+		undefBytes = flag.Bool("undefined-as-bytes", false, "Map defined types missing from the IDL (e.g. padding) to []byte instead of failing")
+		strict     = flag.Bool("strict", false, "Fail on unknown primitive types and malformed IDLs (bad address, unnamed instructions, mismatched discriminators) instead of warning")
+		astTypes   = flag.Bool("ast", false, "Experimental: build the struct declarations of the Types section with go/ast and go/printer instead of the text template")
+		verify     = flag.Bool("verify", false, "Compile the generated code before writing it (requires a Go toolchain)")
 		verbose    = flag.Bool("v", false, "Verbose output")
 	)
 	flag.Parse()
 
+	if *diffOld != "" {
+		if flag.NArg() != 1 {
+			flag.Usage()
+			return
+		}
+		changes, err := idlgen.DiffIDL(*diffOld, flag.Arg(0))
+		if err != nil {
+			log.Fatalf("Error comparing IDLs: %v", err)
+		}
+		if len(changes) == 0 {
+			fmt.Println("No changes")
+		}
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+		return
+	}
+
+	banner := *header
+	if banner != "" {
+		if content, err := os.ReadFile(banner); err == nil {
+			banner = string(content)
+		}
+	}
+
+	var primitiveMap map[string]string
+	if *primMap != "" {
+		content, err := os.ReadFile(*primMap)
+		if err != nil {
+			log.Fatalf("Error reading primitive map: %v", err)
+		}
+		if err := json.Unmarshal(content, &primitiveMap); err != nil {
+			log.Fatalf("Error parsing primitive map %s: %v", *primMap, err)
+		}
+	}
+
+	opts := idlgen.Options{
+		PackageName:        *pkgName,
+		Prefix:             *prefix,
+		GoVersion:          *goVersion,
+		Header:             banner,
+		ClientName:         *clientName,
+		TypeMap:            typeMap,
+		PrimitiveMap:       primitiveMap,
+		JSONTags:           *jsonTags,
+		TagCase:            *tagCase,
+		EnumJSON:           *enumJSON,
+		Equal:              *equal,
+		Validate:           *validate,
+		Describe:           *describe,
+		U128Type:           *u128Type,
+		I128Type:           *i128Type,
+		U256Type:           *u256Type,
+		I256Type:           *i256Type,
+		AllowUnformatted:   *allowUnfmt,
+		Verify:             *verify,
+		AST:                *astTypes,
+		UndefinedAsBytes:   *undefBytes,
+		InlineByteArrays:   *inlineArrs,
+		Strict:             *strict,
+		DiscriminatorMode:  *discMode,
+		NoDiscFallback:     *noFallback,
+		AccountNameCase:    *acctCase,
+		InstrNameCase:      *instrCase,
+		InstrNamespace:     *instrNS,
+		DiscriminatorArray: *discArray,
+		Constructors:       *ctors,
+		OptionHelpers:      *optHelpers,
+		Assertions:         *assertions,
+		ComputeHints:       *cuHints,
+		Builders:           *builders,
+		SPLHelpers:         *splHelpers,
+		CheckAccounts:      *checkAccts,
+		BinImport:          *binImport,
+		SolanaImport:       *solImport,
+		RPCImport:          *rpcImport,
+		Minimal:            *minimal,
+		PubkeyType:         *pubkeyType,
+		PackagePerProgram:  *pkgPerProg,
+		ProtoPath:          *protoPath,
+		TSPath:             *tsPath,
+		Incremental:        *incr,
+		Force:              *force,
+		Jobs:               *jobs,
+		Verbose:            *verbose,
+	}
+
+	if *split {
+		if *idlPath == "" || *outDir == "" || *idlDir != "" || *programID != "" {
+			flag.Usage()
+			return
+		}
+		if err := idlgen.GenerateSplit(*idlPath, *outDir, opts); err != nil {
+			log.Fatalf("Error generating bindings: %v", err)
+		}
+		if *verbose {
+			log.Println("Successfully generated bindings in:", *outDir)
+		}
+		return
+	}
+
+	if *idlDir != "" {
+		if *outDir == "" {
+			flag.Usage()
+			return
+		}
+		if err := idlgen.GenerateDir(*idlDir, *outDir, opts); err != nil {
+			log.Fatalf("Error generating bindings: %v", err)
+		}
+		return
+	}
+
+	if *programID != "" {
+		if *outPath == "" {
+			flag.Usage()
+			return
+		}
+		if err := idlgen.GenerateFromProgram(*rpcURL, *programID, *outPath, opts); err != nil {
+			log.Fatalf("Error generating bindings: %v", err)
+		}
+		if *verbose {
+			log.Println("Successfully generated bindings at:", *outPath)
+		}
+		return
+	}
+
 	if *idlPath == "" || *outPath == "" {
 		flag.Usage()
 		return
 	}
 
-	err := idlgen.Generate(idlPath, outPath, pkgName, clientName, *verbose)
+	err := idlgen.GenerateWithOptions(*idlPath, *outPath, opts)
 	if err != nil {
 		log.Fatalf("Error generating bindings: %v", err)
 	}